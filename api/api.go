@@ -0,0 +1,9 @@
+// Package api embeds the API specifications, so the server can publish them.
+package api
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI document of version 1 of the REST API, generated from openapi.yaml.
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -0,0 +1,105 @@
+// Command importer loads songs from a CSV or NDJSON file into the catalog, the same way as POST /imports.
+//
+//	go run ./cmd/importer -file songs.csv -map group:Artist,song:Title -on-duplicate upsert -dry-run
+//
+// Enrichment fetches missing details from the running API server.
+package main
+
+import (
+	"effectiveMobile/config"
+	"effectiveMobile/internal"
+	repository "effectiveMobile/internal/repository"
+	useCase "effectiveMobile/internal/usecase"
+	"effectiveMobile/pkg/logger"
+	storage "effectiveMobile/pkg/storage/postgres"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	file := flag.String("file", "", "CSV or NDJSON file to import, - for standard input")
+	format := flag.String("format", "", "csv or ndjson, by default taken from the file extension")
+	mapping := flag.String("map", "", "source columns of song fields, e.g. group:Artist,song:Title")
+	dryRun := flag.Bool("dry-run", false, "validate and count without writing")
+	onDuplicate := flag.String("on-duplicate", string(internal.DuplicateSkip), "skip or upsert songs that already exist")
+	enrich := flag.Bool("enrich", false, "fetch missing release dates, lyrics and links")
+	reportPath := flag.String("report", "", "write the row errors as CSV to this file")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(path.Ext(*file), ".")
+		if *format == "jsonl" {
+			*format = "ndjson"
+		}
+	}
+	columns, err := internal.ParseColumnMapping(*mapping)
+	if err != nil {
+		log.Fatalf("Invalid mapping: %v", err)
+	}
+
+	if err = godotenv.Load(); err != nil {
+		log.Printf("No .env file loaded: %v", err)
+	}
+	cfg := config.LoadConfig()
+	appLogger := logger.NewApiLogger(cfg)
+	if err = appLogger.InitLogger(); err != nil {
+		log.Fatalf("Cannot init logger: %v", err)
+	}
+
+	db, err := storage.InitPsqlDB(cfg)
+	if err != nil {
+		log.Fatalf("Cannot connect to Postgres: %v", err)
+	}
+	uc := useCase.NewUseCase(repository.NewPostgresRepository(db, cfg, appLogger), cfg, appLogger)
+
+	var source io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("Cannot open %s: %v", *file, err)
+		}
+		defer f.Close()
+		source = f
+	}
+
+	report, err := uc.ImportSongs(source, internal.ImportOptions{
+		Format:      *format,
+		Mapping:     columns,
+		DryRun:      *dryRun,
+		OnDuplicate: internal.DuplicatePolicy(*onDuplicate),
+		Enrich:      *enrich,
+	})
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	if *reportPath != "" {
+		out, err := os.Create(*reportPath)
+		if err != nil {
+			log.Fatalf("Cannot create %s: %v", *reportPath, err)
+		}
+		if err = report.WriteErrorsCSV(out); err != nil {
+			log.Fatalf("Cannot write %s: %v", *reportPath, err)
+		}
+		if err = out.Close(); err != nil {
+			log.Fatalf("Cannot write %s: %v", *reportPath, err)
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(report); err != nil {
+		log.Fatalf("Cannot print report: %v", err)
+	}
+}
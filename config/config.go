@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -23,6 +25,68 @@ type Config struct {
 	Server struct {
 		Address                     string
 		ShowUnknownErrorsInResponse bool
+		Dev                         bool
+	}
+
+	GRPC struct {
+		Address string
+	}
+
+	Search struct {
+		SimilarityThreshold float64
+	}
+
+	Batch struct {
+		MaxItems int
+		Workers  int
+	}
+
+	Import struct {
+		MaxBytes int
+	}
+
+	GraphQL struct {
+		MaxDepth      int
+		MaxComplexity int
+	}
+
+	Versions struct {
+		Sunset time.Time
+	}
+
+	Validation struct {
+		Strict bool
+	}
+
+	Idempotency struct {
+		TTL         time.Duration
+		LockTimeout time.Duration
+	}
+
+	Admin struct {
+		Token string
+	}
+
+	Webhooks struct {
+		MaxAttempts int
+		Backoff     time.Duration
+		Timeout     time.Duration
+	}
+
+	Events struct {
+		Retention time.Duration
+		Heartbeat time.Duration
+	}
+
+	Outbox struct {
+		Publisher string
+		URL       string
+		Subject   string
+		Timeout   time.Duration
+	}
+
+	Text struct {
+		DefaultLimit int
 	}
 }
 
@@ -53,9 +117,103 @@ func LoadConfig() *Config {
 		Server: struct {
 			Address                     string
 			ShowUnknownErrorsInResponse bool
+			Dev                         bool
 		}{
 			Address:                     os.Getenv("SERVER_ADDRESS"),
 			ShowUnknownErrorsInResponse: false,
+			Dev:                         os.Getenv("APP_ENV") == "development",
+		},
+		GRPC: struct {
+			Address string
+		}{
+			Address: os.Getenv("GRPC_ADDRESS"),
+		},
+		Search: struct {
+			SimilarityThreshold float64
+		}{
+			SimilarityThreshold: getEnvFloat("SEARCH_SIMILARITY_THRESHOLD", 0.3),
+		},
+		Batch: struct {
+			MaxItems int
+			Workers  int
+		}{
+			MaxItems: getEnvInt("BATCH_MAX_ITEMS", 100),
+			Workers:  getEnvInt("BATCH_WORKERS", 8),
+		},
+		Import: struct {
+			MaxBytes int
+		}{
+			MaxBytes: getEnvInt("IMPORT_MAX_BYTES", 64<<20),
+		},
+		GraphQL: struct {
+			MaxDepth      int
+			MaxComplexity int
+		}{
+			MaxDepth:      getEnvInt("GRAPHQL_MAX_DEPTH", 8),
+			MaxComplexity: getEnvInt("GRAPHQL_MAX_COMPLEXITY", 1000),
+		},
+		Versions: struct {
+			Sunset time.Time
+		}{
+			Sunset: getEnvDate("API_V1_SUNSET", time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)),
+		},
+		Validation: struct {
+			Strict bool
+		}{
+			// Response validation costs a copy of every response body, so it is on by default in development only.
+			Strict: getEnvBool("OPENAPI_STRICT", os.Getenv("APP_ENV") == "development"),
+		},
+		Idempotency: struct {
+			TTL         time.Duration
+			LockTimeout time.Duration
+		}{
+			TTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			// A key whose request has not finished after this long is considered abandoned and can be claimed again.
+			LockTimeout: getEnvDuration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute),
+		},
+		Admin: struct {
+			Token string
+		}{
+			// Without a token the admin API is disabled.
+			Token: os.Getenv("ADMIN_TOKEN"),
+		},
+		Webhooks: struct {
+			MaxAttempts int
+			Backoff     time.Duration
+			Timeout     time.Duration
+		}{
+			MaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			// The delay before the first retry, doubled after every further failure.
+			Backoff: getEnvDuration("WEBHOOK_BACKOFF", 30*time.Second),
+			Timeout: getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+		Events: struct {
+			Retention time.Duration
+			Heartbeat time.Duration
+		}{
+			// How long clients of the change feed can be away and still resume where they left off.
+			Retention: getEnvDuration("EVENTS_RETENTION", 24*time.Hour),
+			// Idle feeds are pinged this often, so proxies keep them open and gone clients are noticed.
+			Heartbeat: getEnvDuration("EVENTS_HEARTBEAT", 15*time.Second),
+		},
+		Outbox: struct {
+			Publisher string
+			URL       string
+			Subject   string
+			Timeout   time.Duration
+		}{
+			// memory keeps events in the process; http posts them to URL; nats publishes them to the broker at URL.
+			Publisher: getEnv("OUTBOX_PUBLISHER", "memory"),
+			URL:       os.Getenv("OUTBOX_URL"),
+			// Events are published as <subject>.<event type>, e.g. songs.song.created.
+			Subject: getEnv("OUTBOX_SUBJECT", "songs"),
+			Timeout: getEnvDuration("OUTBOX_TIMEOUT", 10*time.Second),
+		},
+		Text: struct {
+			DefaultLimit int
+		}{
+			// Verses returned when paging through lyrics without a limit. Verse and line selections are not limited.
+			DefaultLimit: getEnvInt("TEXT_DEFAULT_LIMIT", 5),
 		},
 	}
 
@@ -65,3 +223,70 @@ func LoadConfig() *Config {
 
 	return c
 }
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Fatalf("%s must be a boolean: %v", key, err)
+	}
+	return value
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return value
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Fatalf("%s must be an integer: %v", key, err)
+	}
+	return value
+}
+
+func getEnvDate(key string, defaultValue time.Time) time.Time {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		log.Fatalf("%s must be a date formatted as YYYY-MM-DD: %v", key, err)
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Fatalf("%s must be a duration such as 24h or 90s: %v", key, err)
+	}
+	return value
+}
@@ -0,0 +1,72 @@
+package graphql
+
+import (
+	"effectiveMobile/internal"
+	"errors"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// Error codes reported in the extensions of GraphQL errors.
+const (
+	codeBadRequest         = "BAD_REQUEST"
+	codeParseFailed        = "GRAPHQL_PARSE_FAILED"
+	codeValidationFailed   = "GRAPHQL_VALIDATION_FAILED"
+	codeTooComplex         = "QUERY_TOO_COMPLEX"
+	codeBadUserInput       = "BAD_USER_INPUT"
+	codeNotFound           = "NOT_FOUND"
+	codePreconditionFailed = "PRECONDITION_FAILED"
+	codeDetailUnavailable  = "DETAIL_UNAVAILABLE"
+	codeInternal           = "INTERNAL_SERVER_ERROR"
+)
+
+// codedError is an error shown to clients together with a machine-readable code.
+type codedError struct {
+	message string
+	code    string
+}
+
+func (e *codedError) Error() string {
+	return e.message
+}
+
+func (e *codedError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+func newError(code, message string) *codedError {
+	return &codedError{message: message, code: code}
+}
+
+// fail turns a use case error into the error shown to clients. Unexpected errors are logged
+// and reported without details, like the REST handlers do.
+func (h *Handler) fail(err error) error {
+	switch {
+	case errors.Is(err, internal.ErrSongNotFound):
+		return newError(codeNotFound, "song not found")
+	case errors.Is(err, internal.ErrVersionMismatch):
+		return newError(codePreconditionFailed, "the song was changed meanwhile")
+	case errors.Is(err, internal.ErrDetailUnavailable):
+		return newError(codeDetailUnavailable, err.Error())
+	}
+	h.logger.Errorf("GraphQL resolver failed: %v", err)
+	return newError(codeInternal, "InternalServerError")
+}
+
+// errorCode finds the code of an error raised by a resolver. graphql-go keeps the code of errors
+// returned by resolvers directly but drops it for those raised by deferred loader results.
+func errorCode(err error) map[string]any {
+	for err != nil {
+		switch e := err.(type) {
+		case *codedError:
+			return e.Extensions()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
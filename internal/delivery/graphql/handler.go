@@ -0,0 +1,174 @@
+package graphql
+
+import (
+	"context"
+	"effectiveMobile/config"
+	"effectiveMobile/internal"
+	"effectiveMobile/pkg/logger"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Handler serves the GraphQL API, resolving queries and mutations through the same use case
+// as the REST handlers.
+type Handler struct {
+	useCase       internal.UseCase
+	logger        *logger.ApiLogger
+	schema        gql.Schema
+	maxDepth      int
+	maxComplexity int
+}
+
+func NewHandler(useCase internal.UseCase, cfg *config.Config, logger *logger.ApiLogger) (*Handler, error) {
+	h := &Handler{
+		useCase:       useCase,
+		logger:        logger,
+		maxDepth:      cfg.GraphQL.MaxDepth,
+		maxComplexity: cfg.GraphQL.MaxComplexity,
+	}
+	schema, err := h.newSchema()
+	if err != nil {
+		return nil, fmt.Errorf("building GraphQL schema: %v", err)
+	}
+	h.schema = schema
+	return h, nil
+}
+
+// request is a GraphQL request, sent as a JSON body or as query parameters.
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Query executes a GraphQL operation. Requests that cannot be executed at all are answered
+// with 400; errors raised while resolving fields come back with the partial data and 200.
+// GET requests may only read.
+func (h *Handler) Query() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx.UserContext())
+		req, err := parseRequest(ctx)
+		if err != nil {
+			h.logger.Debugf("Invalid GraphQL request: %v", err)
+			return requestError(ctx, fiber.StatusBadRequest, codeBadRequest, err)
+		}
+
+		doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+		if err != nil {
+			h.logger.Debugf("Failed to parse GraphQL query: %v", err)
+			return requestError(ctx, fiber.StatusBadRequest, codeParseFailed, err)
+		}
+		if validation := gql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+			h.logger.Debugf("Invalid GraphQL query: %v", validation.Errors)
+			for i := range validation.Errors {
+				validation.Errors[i].Extensions = map[string]any{"code": codeValidationFailed}
+			}
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"errors": validation.Errors})
+		}
+		if err = checkLimits(doc, req.OperationName, req.Variables, h.maxDepth, h.maxComplexity); err != nil {
+			h.logger.Debugf("Rejected GraphQL query: %v", err)
+			return requestError(ctx, fiber.StatusBadRequest, codeTooComplex, err)
+		}
+		if ctx.Method() == fiber.MethodGet && isMutation(doc, req.OperationName) {
+			ctx.Set(fiber.HeaderAllow, fiber.MethodPost)
+			return requestError(ctx, fiber.StatusMethodNotAllowed, codeBadRequest, fmt.Errorf("mutations must be sent with POST"))
+		}
+
+		result := gql.Execute(gql.ExecuteParams{
+			Schema:        h.schema,
+			AST:           doc,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+			Context:       withLoaders(ctx.UserContext(), h.newLoaders()),
+		})
+		for i, e := range result.Errors {
+			if e.Extensions == nil {
+				result.Errors[i].Extensions = errorCode(e.OriginalError())
+			}
+		}
+
+		h.logger.Infof("Executed GraphQL operation %q with %d errors", req.OperationName, len(result.Errors))
+		return ctx.Status(fiber.StatusOK).JSON(result)
+	}
+}
+
+// bind returns the handler serving the request of ctx, with the use case and logger bound to its
+// request ID.
+func (h *Handler) bind(ctx context.Context) *Handler {
+	bound := *h
+	bound.useCase = h.useCase.WithContext(ctx)
+	bound.logger = h.logger.WithContext(ctx)
+	return &bound
+}
+
+func parseRequest(ctx fiber.Ctx) (*request, error) {
+	req := &request{}
+	if ctx.Method() == fiber.MethodGet {
+		req.Query = ctx.Query("query")
+		req.OperationName = ctx.Query("operationName")
+		if raw := ctx.Query("variables"); raw != "" {
+			if err := ctx.App().Config().JSONDecoder([]byte(raw), &req.Variables); err != nil {
+				return nil, fmt.Errorf("variables must be a JSON object")
+			}
+		}
+	} else if err := ctx.Bind().JSON(req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %v", err)
+	}
+
+	if req.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	return req, nil
+}
+
+func isMutation(doc *ast.Document, operationName string) bool {
+	for _, definition := range doc.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			if operationName == "" || (operation.Name != nil && operation.Name.Value == operationName) {
+				return operation.Operation == ast.OperationTypeMutation
+			}
+		}
+	}
+	return false
+}
+
+func requestError(ctx fiber.Ctx, status int, code string, err error) error {
+	formatted := gqlerrors.FormatError(err)
+	formatted.Extensions = map[string]any{"code": code}
+	return ctx.Status(status).JSON(fiber.Map{"errors": []gqlerrors.FormattedError{formatted}})
+}
+
+// GraphiQL serves the in-browser IDE, which is only mapped in dev mode.
+func (h *Handler) GraphiQL() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return ctx.Status(fiber.StatusOK).SendString(graphiQLPage)
+	}
+}
+
+const graphiQLPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>GraphiQL</title>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+  <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+</head>
+<body>
+  <div id="graphiql"></div>
+  <script src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: '/graphql' });
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(React.createElement(GraphiQL, { fetcher }));
+  </script>
+</body>
+</html>
+`
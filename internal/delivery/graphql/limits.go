@@ -0,0 +1,103 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultListSizes is the number of items list fields return when no limit argument is given.
+var defaultListSizes = map[string]int{
+	"songs":  10,
+	"lyrics": 5,
+}
+
+// checkLimits rejects an operation nested deeper than maxDepth fields or costing more than
+// maxComplexity. Every field costs one, and the fields below a field with a limit argument
+// are counted once per requested item. Introspection fields are free, so GraphiQL works.
+func checkLimits(doc *ast.Document, operationName string, variables map[string]any, maxDepth, maxComplexity int) error {
+	fragments := make(map[string]ast.Definition)
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operation = d
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+
+	depth, complexity := measure(operation.SelectionSet, fragments, variables)
+	if depth > maxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, maxDepth)
+	}
+	if complexity > maxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, maxComplexity)
+	}
+	return nil
+}
+
+// measure returns the depth and cost of a selection set. Fragment cycles are rejected by
+// validation beforehand, so the recursion ends.
+func measure(set *ast.SelectionSet, fragments map[string]ast.Definition, variables map[string]any) (int, int) {
+	depth, complexity := 0, 0
+	eachField(set, fragments, func(field *ast.Field) {
+		if strings.HasPrefix(field.Name.Value, "__") {
+			return
+		}
+		childDepth, childComplexity := measure(field.SelectionSet, fragments, variables)
+		depth = max(depth, childDepth+1)
+		complexity += 1 + listSize(field, variables)*childComplexity
+	})
+	return depth, complexity
+}
+
+// listSize reads the limit argument of a field, literal or variable.
+func listSize(field *ast.Field, variables map[string]any) int {
+	size := 1
+	if n, ok := defaultListSizes[field.Name.Value]; ok {
+		size = n
+	}
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				size = n
+			}
+		case *ast.Variable:
+			if n, ok := variables[value.Name.Value].(float64); ok {
+				size = int(n)
+			}
+		}
+	}
+	return max(size, 1)
+}
+
+// eachField calls fn for the fields of a selection set, looking into fragments.
+func eachField(set *ast.SelectionSet, fragments map[string]ast.Definition, fn func(field *ast.Field)) {
+	if set == nil {
+		return
+	}
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			fn(s)
+		case *ast.InlineFragment:
+			eachField(s.SelectionSet, fragments, fn)
+		case *ast.FragmentSpread:
+			if fragment, ok := fragments[s.Name.Value].(*ast.FragmentDefinition); ok {
+				eachField(fragment.SelectionSet, fragments, fn)
+			}
+		}
+	}
+}
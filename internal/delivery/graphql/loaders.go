@@ -0,0 +1,78 @@
+package graphql
+
+import (
+	"context"
+	"effectiveMobile/internal"
+	"time"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loaderWait is how long a loader collects keys before querying. Sibling fields of a list are
+// resolved one after another without blocking, so their keys arrive well within it.
+const loaderWait = 2 * time.Millisecond
+
+type loadersKey struct{}
+
+// loaders batch the related resources of the songs of one request, so a page of songs costs
+// one query per resource instead of one per song.
+type loaders struct {
+	artists *dataloader.Loader[string, *internal.Artist]
+	details *dataloader.Loader[internal.SongKey, *openapi.SongDetail]
+}
+
+func (h *Handler) newLoaders() *loaders {
+	return &loaders{
+		artists: dataloader.NewBatchedLoader(h.loadArtists, dataloader.WithWait[string, *internal.Artist](loaderWait)),
+		details: dataloader.NewBatchedLoader(h.loadSongDetails, dataloader.WithWait[internal.SongKey, *openapi.SongDetail](loaderWait)),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (h *Handler) loadArtists(_ context.Context, names []string) []*dataloader.Result[*internal.Artist] {
+	results := make([]*dataloader.Result[*internal.Artist], len(names))
+	artists, err := h.useCase.GetArtists(names)
+	if err != nil {
+		err = h.fail(err)
+	}
+	byName := make(map[string]*internal.Artist, len(artists))
+	for _, artist := range artists {
+		byName[artist.Name] = artist
+	}
+	for i, name := range names {
+		results[i] = &dataloader.Result[*internal.Artist]{Data: byName[name], Error: err}
+	}
+	return results
+}
+
+func (h *Handler) loadSongDetails(_ context.Context, keys []internal.SongKey) []*dataloader.Result[*openapi.SongDetail] {
+	results := make([]*dataloader.Result[*openapi.SongDetail], len(keys))
+	details, err := h.useCase.GetSongDetails(keys)
+	if err != nil {
+		err = h.fail(err)
+	}
+	for i, key := range keys {
+		results[i] = &dataloader.Result[*openapi.SongDetail]{Data: details[key], Error: err}
+	}
+	return results
+}
+
+// thunk adapts a loader result to the deferred form graphql-go resolves once the whole level
+// of the query has been visited.
+func thunk[V any](load dataloader.Thunk[V]) func() (any, error) {
+	return func() (any, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
@@ -0,0 +1,15 @@
+package graphql
+
+import (
+	"github.com/gofiber/fiber/v3"
+)
+
+// MapRoutes maps the GraphQL endpoint, plus GraphiQL when dev is set.
+func MapRoutes(r fiber.Router, h *Handler, dev bool) {
+	r.Get(`graphql`, h.Query())
+	r.Post(`graphql`, h.Query())
+
+	if dev {
+		r.Get(`graphiql`, h.GraphiQL())
+	}
+}
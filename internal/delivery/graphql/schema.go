@@ -0,0 +1,390 @@
+package graphql
+
+import (
+	"effectiveMobile/internal"
+	"slices"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// songColumns maps the fields of the Song type to the song fields they are resolved from,
+// so only the selected columns are loaded.
+var songColumns = map[string][]string{
+	"group":       {"group"},
+	"song":        {"song"},
+	"releaseDate": {"releaseDate"},
+	"text":        {"text"},
+	"link":        {"link"},
+	"lyrics":      {"text"},
+	"artist":      {"group"},
+	"detail":      {"group", "song"},
+}
+
+// verse is a numbered verse of song lyrics.
+type verse struct {
+	Number int      `json:"number"`
+	Lines  []string `json:"lines"`
+}
+
+func (h *Handler) newSchema() (gql.Schema, error) {
+	artistType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Artist",
+		Description: "The performer of songs, identified by the group name.",
+		Fields: gql.Fields{
+			"name":      &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"songCount": &gql.Field{Type: gql.NewNonNull(gql.Int)},
+		},
+	})
+
+	songDetailType := gql.NewObject(gql.ObjectConfig{
+		Name:        "SongDetail",
+		Description: "Details of a song as provided by the song detail service.",
+		Fields: gql.Fields{
+			"releaseDate": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"text":        &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"link":        &gql.Field{Type: gql.NewNonNull(gql.String)},
+		},
+	})
+
+	verseType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Verse",
+		Description: "A verse of song lyrics, numbered from 1.",
+		Fields: gql.Fields{
+			"number": &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"lines":  &gql.Field{Type: gql.NewNonNull(gql.NewList(gql.NewNonNull(gql.String)))},
+		},
+	})
+
+	songType := gql.NewObject(gql.ObjectConfig{
+		Name: "Song",
+		Fields: gql.Fields{
+			"id":          &gql.Field{Type: gql.NewNonNull(gql.ID)},
+			"version":     &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"group":       &gql.Field{Type: gql.String},
+			"song":        &gql.Field{Type: gql.String},
+			"releaseDate": &gql.Field{Type: gql.String},
+			"text":        &gql.Field{Type: gql.String},
+			"link":        &gql.Field{Type: gql.String},
+			"lyrics": &gql.Field{
+				Type:        gql.NewNonNull(gql.NewList(gql.NewNonNull(verseType))),
+				Description: "A page of the lyrics split into verses.",
+				Args: gql.FieldConfigArgument{
+					"offset": &gql.ArgumentConfig{Type: gql.Int, DefaultValue: 0},
+					"limit":  &gql.ArgumentConfig{Type: gql.Int, DefaultValue: defaultListSizes["lyrics"]},
+				},
+				Resolve: resolveLyrics,
+			},
+			"artist": &gql.Field{
+				Type: artistType,
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					view := p.Source.(*internal.SongView)
+					if view.Group == nil {
+						return nil, nil
+					}
+					return thunk(loadersFrom(p.Context).artists.Load(p.Context, *view.Group)), nil
+				},
+			},
+			"detail": &gql.Field{
+				Type:        songDetailType,
+				Description: "The stored details of the song, if any.",
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					view := p.Source.(*internal.SongView)
+					if view.Group == nil || view.Song == nil {
+						return nil, nil
+					}
+					key := internal.SongKey{Group: *view.Group, Song: *view.Song}
+					return thunk(loadersFrom(p.Context).details.Load(p.Context, key)), nil
+				},
+			},
+		},
+	})
+
+	songsPageType := gql.NewObject(gql.ObjectConfig{
+		Name: "SongsPage",
+		Fields: gql.Fields{
+			"items":      &gql.Field{Type: gql.NewNonNull(gql.NewList(gql.NewNonNull(songType)))},
+			"nextCursor": &gql.Field{Type: gql.String},
+			"prevCursor": &gql.Field{Type: gql.String},
+			"total":      &gql.Field{Type: gql.Int},
+		},
+	})
+
+	query := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"song": &gql.Field{
+				Type: songType,
+				Args: gql.FieldConfigArgument{
+					"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.ID)},
+				},
+				Resolve: h.resolveSong,
+			},
+			"songs": &gql.Field{
+				Type: gql.NewNonNull(songsPageType),
+				Args: gql.FieldConfigArgument{
+					"group":       &gql.ArgumentConfig{Type: gql.String},
+					"song":        &gql.ArgumentConfig{Type: gql.String},
+					"releaseDate": &gql.ArgumentConfig{Type: gql.String},
+					"text":        &gql.ArgumentConfig{Type: gql.String},
+					"link":        &gql.ArgumentConfig{Type: gql.String},
+					"fuzzy":       &gql.ArgumentConfig{Type: gql.Boolean, DefaultValue: false},
+					"sort":        &gql.ArgumentConfig{Type: gql.String},
+					"limit":       &gql.ArgumentConfig{Type: gql.Int},
+					"cursor":      &gql.ArgumentConfig{Type: gql.String},
+				},
+				Resolve: h.resolveSongs,
+			},
+			"songDetail": &gql.Field{
+				Type: songDetailType,
+				Args: gql.FieldConfigArgument{
+					"group": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+					"song":  &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+				},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					key := internal.SongKey{Group: p.Args["group"].(string), Song: p.Args["song"].(string)}
+					return thunk(loadersFrom(p.Context).details.Load(p.Context, key)), nil
+				},
+			},
+		},
+	})
+
+	createSongInput := gql.NewInputObject(gql.InputObjectConfig{
+		Name: "CreateSongInput",
+		Fields: gql.InputObjectConfigFieldMap{
+			"group": &gql.InputObjectFieldConfig{Type: gql.NewNonNull(gql.String)},
+			"song":  &gql.InputObjectFieldConfig{Type: gql.NewNonNull(gql.String)},
+		},
+	})
+
+	updateSongInput := gql.NewInputObject(gql.InputObjectConfig{
+		Name: "UpdateSongInput",
+		Fields: gql.InputObjectConfigFieldMap{
+			"group":       &gql.InputObjectFieldConfig{Type: gql.String},
+			"song":        &gql.InputObjectFieldConfig{Type: gql.String},
+			"releaseDate": &gql.InputObjectFieldConfig{Type: gql.String},
+			"text":        &gql.InputObjectFieldConfig{Type: gql.String},
+			"link":        &gql.InputObjectFieldConfig{Type: gql.String},
+		},
+	})
+
+	mutation := gql.NewObject(gql.ObjectConfig{
+		Name: "Mutation",
+		Fields: gql.Fields{
+			"createSong": &gql.Field{
+				Type:        gql.NewNonNull(songType),
+				Description: "Creates a song with the details fetched from the song detail service.",
+				Args: gql.FieldConfigArgument{
+					"input": &gql.ArgumentConfig{Type: gql.NewNonNull(createSongInput)},
+				},
+				Resolve: h.resolveCreateSong,
+			},
+			"updateSong": &gql.Field{
+				Type:        gql.NewNonNull(songType),
+				Description: "Changes the given fields. With ifVersion set it fails if the song was changed meanwhile.",
+				Args: gql.FieldConfigArgument{
+					"id":        &gql.ArgumentConfig{Type: gql.NewNonNull(gql.ID)},
+					"input":     &gql.ArgumentConfig{Type: gql.NewNonNull(updateSongInput)},
+					"ifVersion": &gql.ArgumentConfig{Type: gql.Int},
+				},
+				Resolve: h.resolveUpdateSong,
+			},
+			"deleteSong": &gql.Field{
+				Type:        gql.NewNonNull(gql.ID),
+				Description: "Deletes the song and returns its ID. With ifVersion set it fails if the song was changed meanwhile.",
+				Args: gql.FieldConfigArgument{
+					"id":        &gql.ArgumentConfig{Type: gql.NewNonNull(gql.ID)},
+					"ifVersion": &gql.ArgumentConfig{Type: gql.Int},
+				},
+				Resolve: h.resolveDeleteSong,
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func (h *Handler) resolveSong(p gql.ResolveParams) (any, error) {
+	h = h.bind(p.Context)
+	id := p.Args["id"].(string)
+	limit := int32(1)
+	q := &internal.SongsQuery{
+		Filter: openapi.GetSongsBody{Id: &id, Limit: &limit},
+		Fields: songFields(selectedFields(p.Info)),
+	}
+	page, err := h.useCase.GetSongs(q)
+	if err != nil {
+		return nil, h.fail(err)
+	}
+	if len(page.Items) == 0 {
+		return nil, nil
+	}
+	return page.Items[0], nil
+}
+
+func (h *Handler) resolveSongs(p gql.ResolveParams) (any, error) {
+	h = h.bind(p.Context)
+	q := &internal.SongsQuery{
+		Filter: openapi.GetSongsBody{
+			Group:       stringArg(p.Args, "group"),
+			Song:        stringArg(p.Args, "song"),
+			ReleaseDate: stringArg(p.Args, "releaseDate"),
+			Text:        stringArg(p.Args, "text"),
+			Link:        stringArg(p.Args, "link"),
+		},
+		Fuzzy:     p.Args["fuzzy"].(bool),
+		Fields:    songFields(selectedFields(p.Info, "items")),
+		WithTotal: slices.Contains(selectedFields(p.Info), "total"),
+	}
+	if limit, ok := p.Args["limit"].(int); ok {
+		if limit < 1 {
+			return nil, newError(codeBadUserInput, "limit must be positive")
+		}
+		size := int32(limit)
+		q.Filter.Limit = &size
+	}
+
+	var err error
+	if sort := stringArg(p.Args, "sort"); sort != nil {
+		if q.Sort, err = internal.ParseSort(*sort, internal.SongSortFields); err != nil {
+			return nil, newError(codeBadUserInput, err.Error())
+		}
+	}
+	if token := stringArg(p.Args, "cursor"); token != nil {
+		if q.Cursor, err = internal.DecodeCursorFor(*token, q.Sort); err != nil {
+			return nil, newError(codeBadUserInput, err.Error())
+		}
+	}
+
+	page, err := h.useCase.GetSongs(q)
+	if err != nil {
+		return nil, h.fail(err)
+	}
+	return page, nil
+}
+
+func resolveLyrics(p gql.ResolveParams) (any, error) {
+	view := p.Source.(*internal.SongView)
+	offset, limit := p.Args["offset"].(int), p.Args["limit"].(int)
+	if offset < 0 || limit < 0 {
+		return nil, newError(codeBadUserInput, "offset and limit must not be negative")
+	}
+
+	var verses []verse
+	if view.Text != nil {
+		for i, lines := range internal.SplitVerses(*view.Text) {
+			verses = append(verses, verse{Number: i + 1, Lines: lines})
+		}
+	}
+	start := min(offset, len(verses))
+	end := min(start+limit, len(verses))
+	return verses[start:end], nil
+}
+
+func (h *Handler) resolveCreateSong(p gql.ResolveParams) (any, error) {
+	h = h.bind(p.Context)
+	input := p.Args["input"].(map[string]any)
+	req := openapi.CreateSongBody{Group: input["group"].(string), Song: input["song"].(string)}
+	if req.Group == "" || req.Song == "" {
+		return nil, newError(codeBadUserInput, "group and song are required")
+	}
+
+	detail, err := h.useCase.FetchSongDetail(req.Group, req.Song)
+	if err != nil {
+		h.logger.Errorf("Failed to fetch song detail: %v", err)
+		return nil, newError(codeDetailUnavailable, internal.ErrDetailUnavailable.Error())
+	}
+	created, err := h.useCase.CreateSong(req, detail)
+	if err != nil {
+		return nil, h.fail(err)
+	}
+	return internal.NewSongView(created, nil), nil
+}
+
+func (h *Handler) resolveUpdateSong(p gql.ResolveParams) (any, error) {
+	h = h.bind(p.Context)
+	input := p.Args["input"].(map[string]any)
+	body := &openapi.UpdateSongBody{
+		Group:       stringArg(input, "group"),
+		Song:        stringArg(input, "song"),
+		ReleaseDate: stringArg(input, "releaseDate"),
+		Text:        stringArg(input, "text"),
+		Link:        stringArg(input, "link"),
+	}
+	if body.Group == nil && body.Song == nil && body.ReleaseDate == nil && body.Text == nil && body.Link == nil {
+		return nil, newError(codeBadUserInput, "at least one field must be changed")
+	}
+
+	updated, err := h.useCase.UpdateSong(p.Args["id"].(string), body, versionArg(p.Args))
+	if err != nil {
+		return nil, h.fail(err)
+	}
+	return internal.NewSongView(updated, nil), nil
+}
+
+func (h *Handler) resolveDeleteSong(p gql.ResolveParams) (any, error) {
+	h = h.bind(p.Context)
+	id := p.Args["id"].(string)
+	if err := h.useCase.DeleteSong(id, versionArg(p.Args)); err != nil {
+		return nil, h.fail(err)
+	}
+	return id, nil
+}
+
+// selectedFields returns the names of the fields selected below the resolved field,
+// following path through nested fields.
+func selectedFields(info gql.ResolveInfo, path ...string) []string {
+	var sets []*ast.SelectionSet
+	for _, field := range info.FieldASTs {
+		sets = append(sets, field.SelectionSet)
+	}
+	for _, name := range path {
+		var nested []*ast.SelectionSet
+		for _, set := range sets {
+			eachField(set, info.Fragments, func(field *ast.Field) {
+				if field.Name.Value == name {
+					nested = append(nested, field.SelectionSet)
+				}
+			})
+		}
+		sets = nested
+	}
+
+	var names []string
+	for _, set := range sets {
+		eachField(set, info.Fragments, func(field *ast.Field) {
+			names = append(names, field.Name.Value)
+		})
+	}
+	return names
+}
+
+// songFields returns the song fields needed to resolve the selected Song fields.
+// The ID and version are always loaded.
+func songFields(selected []string) []string {
+	fields := []string{"id"}
+	for _, field := range internal.SongFields {
+		for _, name := range selected {
+			if slices.Contains(songColumns[name], field) && !slices.Contains(fields, field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+func stringArg(args map[string]any, name string) *string {
+	if value, ok := args[name].(string); ok {
+		return &value
+	}
+	return nil
+}
+
+func versionArg(args map[string]any) *int64 {
+	if value, ok := args["ifVersion"].(int); ok {
+		version := int64(value)
+		return &version
+	}
+	return nil
+}
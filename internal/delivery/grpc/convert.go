@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"effectiveMobile/internal"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	songsv1 "effectiveMobile/pkg/api/songs/v1"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// songsQuery builds a listing query from the request. Without fields, defaultFields are selected.
+func songsQuery(filter *songsv1.SongFilter, sort string, fields, defaultFields []string) (*internal.SongsQuery, error) {
+	q := &internal.SongsQuery{
+		Filter: openapiFilter(filter),
+		Fuzzy:  filter.GetFuzzy(),
+		Fields: defaultFields,
+	}
+
+	var err error
+	if q.Sort, err = internal.ParseSort(sort, internal.SongSortFields); err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		q.Fields = nil
+		for _, field := range fields {
+			if !slices.Contains(internal.SongFields, field) {
+				return nil, fmt.Errorf("fields: unknown value %q, expected one of %s", field, strings.Join(internal.SongFields, ", "))
+			}
+			if !slices.Contains(q.Fields, field) {
+				q.Fields = append(q.Fields, field)
+			}
+		}
+	}
+	return q, nil
+}
+
+func openapiFilter(filter *songsv1.SongFilter) openapi.GetSongsBody {
+	if filter == nil {
+		return openapi.GetSongsBody{}
+	}
+	return openapi.GetSongsBody{
+		Id:          filter.Id,
+		Group:       filter.Group,
+		Song:        filter.Song,
+		ReleaseDate: filter.ReleaseDate,
+		Text:        filter.Text,
+		Link:        filter.Link,
+	}
+}
+
+func songMessage(view *internal.SongView) *songsv1.Song {
+	return &songsv1.Song{
+		Id:          view.Id,
+		Version:     view.Version,
+		Group:       value(view.Group),
+		Song:        value(view.Song),
+		ReleaseDate: value(view.ReleaseDate),
+		Text:        value(view.Text),
+		Link:        value(view.Link),
+	}
+}
+
+// statusError maps use case errors to gRPC status codes. Unexpected errors are logged and
+// reported without details, like the REST handlers do.
+func (h *Handler) statusError(err error) error {
+	switch {
+	case errors.Is(err, internal.ErrSongNotFound):
+		return status.Error(codes.NotFound, internal.ErrSongNotFound.Error())
+	case errors.Is(err, internal.ErrVersionMismatch):
+		return status.Error(codes.Aborted, internal.ErrVersionMismatch.Error())
+	case errors.Is(err, internal.ErrInvalidSort), errors.Is(err, internal.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, internal.ErrDetailUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	h.logger.Errorf("gRPC call failed: %v", err)
+	return status.Error(codes.Internal, "InternalServerError")
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,200 @@
+package grpc
+
+import (
+	"context"
+	"effectiveMobile/internal"
+	"effectiveMobile/pkg/logger"
+
+	songsv1 "effectiveMobile/pkg/api/songs/v1"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+//go:generate protoc -I ../../../api/proto --go_out=../../.. --go_opt=module=effectiveMobile --go-grpc_out=../../.. --go-grpc_opt=module=effectiveMobile songs/v1/songs.proto
+
+// Handler implements the SongService on top of the same use case as the REST handlers.
+type Handler struct {
+	songsv1.UnimplementedSongServiceServer
+	useCase internal.UseCase
+	logger  *logger.ApiLogger
+}
+
+func NewHandler(useCase internal.UseCase, logger *logger.ApiLogger) *Handler {
+	return &Handler{useCase: useCase, logger: logger}
+}
+
+// bind returns the handler serving the call of ctx, with the use case and logger bound to its
+// request ID.
+func (h *Handler) bind(ctx context.Context) *Handler {
+	bound := *h
+	bound.useCase = h.useCase.WithContext(ctx)
+	bound.logger = h.logger.WithContext(ctx)
+	return &bound
+}
+
+func (h *Handler) GetSongDetail(ctx context.Context, req *songsv1.GetSongDetailRequest) (*songsv1.SongDetail, error) {
+	h = h.bind(ctx)
+	if req.Group == "" || req.Song == "" {
+		return nil, status.Error(codes.InvalidArgument, "group and song are required")
+	}
+
+	detail, err := h.useCase.GetSongDetail(req.Group, req.Song)
+	if err != nil {
+		return nil, h.statusError(err)
+	}
+
+	h.logger.Infof("Successfully fetched song detail for group: %s, song: %s", req.Group, req.Song)
+	return &songsv1.SongDetail{ReleaseDate: detail.ReleaseDate, Text: detail.Text, Link: detail.Link}, nil
+}
+
+func (h *Handler) GetSongs(ctx context.Context, req *songsv1.GetSongsRequest) (*songsv1.GetSongsResponse, error) {
+	h = h.bind(ctx)
+	q, err := songsQuery(req.Filter, req.Sort, req.Fields, internal.DefaultListFields)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+	if req.Limit > 0 {
+		q.Filter.Limit = &req.Limit
+	}
+	if req.Cursor != "" {
+		if q.Cursor, err = internal.DecodeCursorFor(req.Cursor, q.Sort); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	q.WithTotal = req.WithTotal
+
+	page, err := h.useCase.GetSongs(q)
+	if err != nil {
+		return nil, h.statusError(err)
+	}
+
+	resp := &songsv1.GetSongsResponse{Songs: make([]*songsv1.Song, len(page.Items)), Total: page.Total}
+	for i, view := range page.Items {
+		resp.Songs[i] = songMessage(view)
+	}
+	if page.NextCursor != nil {
+		resp.NextCursor = *page.NextCursor
+	}
+	if page.PrevCursor != nil {
+		resp.PrevCursor = *page.PrevCursor
+	}
+	h.logger.Infof("Successfully fetched songs, count: %d", len(resp.Songs))
+	return resp, nil
+}
+
+// StreamSongs sends the songs as they are read from the database. A client that goes away
+// stops the export at the next song.
+func (h *Handler) StreamSongs(req *songsv1.StreamSongsRequest, stream songsv1.SongService_StreamSongsServer) error {
+	h = h.bind(stream.Context())
+	q, err := songsQuery(req.Filter, req.Sort, req.Fields, nil)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sent := 0
+	err = h.useCase.ExportSongs(q, func(view *internal.SongView) error {
+		if err := stream.Context().Err(); err != nil {
+			return err
+		}
+		sent++
+		return stream.Send(songMessage(view))
+	})
+	if ctxErr := stream.Context().Err(); ctxErr != nil {
+		h.logger.Debugf("Song stream cancelled after %d songs", sent)
+		return status.FromContextError(ctxErr).Err()
+	}
+	if err != nil {
+		return h.statusError(err)
+	}
+
+	h.logger.Infof("Successfully streamed %d songs", sent)
+	return nil
+}
+
+func (h *Handler) GetSongText(ctx context.Context, req *songsv1.GetSongTextRequest) (*songsv1.GetSongTextResponse, error) {
+	h = h.bind(ctx)
+	if req.Group == "" || req.Song == "" {
+		return nil, status.Error(codes.InvalidArgument, "group and song are required")
+	}
+	if req.Offset < 0 || req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset and limit must not be negative")
+	}
+
+	text, err := h.useCase.GetSongText(&internal.TextQuery{Group: req.Group, Song: req.Song, Offset: &req.Offset, Limit: req.Limit})
+	if err != nil {
+		return nil, h.statusError(err)
+	}
+
+	resp := &songsv1.GetSongTextResponse{Verses: make([]*songsv1.Verse, len(text.Verses))}
+	for i, lines := range text.Verses {
+		resp.Verses[i] = &songsv1.Verse{Lines: lines}
+	}
+	h.logger.Infof("Successfully fetched song text for group: %s, song: %s", req.Group, req.Song)
+	return resp, nil
+}
+
+func (h *Handler) CreateSong(ctx context.Context, req *songsv1.CreateSongRequest) (*songsv1.Song, error) {
+	h = h.bind(ctx)
+	if req.Group == "" || req.Song == "" {
+		return nil, status.Error(codes.InvalidArgument, "group and song are required")
+	}
+
+	body := openapi.CreateSongBody{Group: req.Group, Song: req.Song}
+	detail, err := h.useCase.FetchSongDetail(body.Group, body.Song)
+	if err != nil {
+		h.logger.Errorf("Failed to fetch song detail: %v", err)
+		return nil, status.Error(codes.Unavailable, internal.ErrDetailUnavailable.Error())
+	}
+	created, err := h.useCase.CreateSong(body, detail)
+	if err != nil {
+		return nil, h.statusError(err)
+	}
+
+	h.logger.Infof("Successfully created song for group: %s, song: %s", req.Group, req.Song)
+	return songMessage(internal.NewSongView(created, nil)), nil
+}
+
+func (h *Handler) UpdateSong(ctx context.Context, req *songsv1.UpdateSongRequest) (*songsv1.Song, error) {
+	h = h.bind(ctx)
+	body := &openapi.UpdateSongBody{
+		Group:       req.Group,
+		Song:        req.Song,
+		ReleaseDate: req.ReleaseDate,
+		Text:        req.Text,
+		Link:        req.Link,
+	}
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if body.Group == nil && body.Song == nil && body.ReleaseDate == nil && body.Text == nil && body.Link == nil {
+		return nil, status.Error(codes.InvalidArgument, "at least one field must be changed")
+	}
+
+	updated, err := h.useCase.UpdateSong(req.Id, body, req.IfVersion)
+	if err != nil {
+		return nil, h.statusError(err)
+	}
+
+	h.logger.Infof("Successfully updated song with ID: %s", req.Id)
+	return songMessage(internal.NewSongView(updated, nil)), nil
+}
+
+func (h *Handler) DeleteSong(ctx context.Context, req *songsv1.DeleteSongRequest) (*emptypb.Empty, error) {
+	h = h.bind(ctx)
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := h.useCase.DeleteSong(req.Id, req.IfVersion); err != nil {
+		return nil, h.statusError(err)
+	}
+
+	h.logger.Infof("Successfully deleted song with ID: %s", req.Id)
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"effectiveMobile/internal"
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// batchBody is the request of the batch endpoints. Mode defaults to a single transaction.
+type batchBody[T any] struct {
+	Mode  internal.BatchMode `json:"mode"`
+	Items []T                `json:"items"`
+}
+
+func bindBatch[T any](ctx fiber.Ctx) (*batchBody[T], error) {
+	var body batchBody[T]
+	if err := ctx.Bind().Body(&body); err != nil {
+		return nil, err
+	}
+	if body.Mode == "" {
+		body.Mode = internal.BatchTransaction
+	}
+	return &body, nil
+}
+
+// batchItemStatus is the status an item would have been answered with on its own.
+func batchItemStatus(err error) int {
+	switch {
+	case err == nil:
+		return fiber.StatusOK
+	case errors.Is(err, internal.ErrInvalidItem):
+		return fiber.StatusBadRequest
+	case errors.Is(err, internal.ErrSongNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, internal.ErrVersionMismatch):
+		return fiber.StatusPreconditionFailed
+	case errors.Is(err, internal.ErrRolledBack):
+		return fiber.StatusFailedDependency
+	case errors.Is(err, internal.ErrDetailUnavailable):
+		return fiber.StatusBadGateway
+	}
+	return fiber.StatusInternalServerError
+}
+
+// writeBatch answers 200 when every item succeeded and 207 with the per-item statuses otherwise.
+func (h Handler) writeBatch(ctx fiber.Ctx, response *internal.BatchResponse, err error) error {
+	if errors.Is(err, internal.ErrInvalidBatch) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to run batch: %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+	}
+
+	for i := range response.Results {
+		result := &response.Results[i]
+		result.Status = batchItemStatus(result.Err)
+		if result.Status == fiber.StatusInternalServerError {
+			h.logger.Errorf("Batch item %d failed: %v", result.Index, result.Err)
+			result.Error = "InternalServerError"
+		} else if result.Err != nil {
+			result.Error = result.Err.Error()
+		}
+	}
+
+	if response.Failed > 0 {
+		return ctx.Status(fiber.StatusMultiStatus).JSON(response)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"effectiveMobile/internal"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestBatchItemStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: nil, want: fiber.StatusOK},
+		{err: fmt.Errorf("%w: id is required", internal.ErrInvalidItem), want: fiber.StatusBadRequest},
+		{err: fmt.Errorf("updating song: %w", internal.ErrSongNotFound), want: fiber.StatusNotFound},
+		{err: internal.ErrVersionMismatch, want: fiber.StatusPreconditionFailed},
+		{err: internal.ErrRolledBack, want: fiber.StatusFailedDependency},
+		{err: fmt.Errorf("%w: timeout", internal.ErrDetailUnavailable), want: fiber.StatusBadGateway},
+		{err: errors.New("connection reset"), want: fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := batchItemStatus(tt.err); got != tt.want {
+			t.Errorf("batchItemStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// BodyLimit reads request bodies into memory up to the limit of their route and answers 413
+// beyond it. The server streams request bodies, so nothing above the limit is buffered; limit
+// lets routes such as catalog uploads accept more than the default.
+func BodyLimit(limit func(ctx fiber.Ctx) int) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		req := ctx.Request()
+		if !req.IsBodyStream() {
+			return ctx.Next()
+		}
+
+		maxBytes := limit(ctx)
+		var body []byte
+		var err error
+		if req.Header.ContentLength() <= maxBytes {
+			body, err = io.ReadAll(io.LimitReader(req.BodyStream(), int64(maxBytes)+1))
+			if err != nil {
+				ctx.Response().SetConnectionClose()
+				return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+			}
+		}
+		if req.Header.ContentLength() > maxBytes || len(body) > maxBytes {
+			// The rest of the body is left unread, so the connection cannot serve another request.
+			ctx.Response().SetConnectionClose()
+			return ctx.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": fmt.Sprintf("request body exceeds %d bytes", maxBytes)})
+		}
+		req.SetBody(body)
+		return ctx.Next()
+	}
+}
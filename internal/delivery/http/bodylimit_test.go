@@ -0,0 +1,58 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestBodyLimit(t *testing.T) {
+	app := fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true})
+	app.Use(BodyLimit(func(ctx fiber.Ctx) int {
+		if ctx.Path() == "/imports" {
+			return 2 * fiber.DefaultBodyLimit
+		}
+		return fiber.DefaultBodyLimit
+	}))
+	app.Post("/*", func(ctx fiber.Ctx) error {
+		return ctx.SendString(strconv.Itoa(len(ctx.Body())))
+	})
+
+	tests := []struct {
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{path: "/songs", size: 100, want: fiber.StatusOK},
+		{path: "/songs", size: fiber.DefaultBodyLimit, want: fiber.StatusOK},
+		{path: "/songs", size: fiber.DefaultBodyLimit + 1, want: fiber.StatusRequestEntityTooLarge},
+		{path: "/songs", size: fiber.DefaultBodyLimit + 1, chunked: true, want: fiber.StatusRequestEntityTooLarge},
+		{path: "/imports", size: fiber.DefaultBodyLimit + 1, want: fiber.StatusOK},
+		{path: "/imports", size: fiber.DefaultBodyLimit + 1, chunked: true, want: fiber.StatusOK},
+		{path: "/imports", size: 2*fiber.DefaultBodyLimit + 1, want: fiber.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		body := bytes.Repeat([]byte("a"), tt.size)
+		req := httptest.NewRequest(fiber.MethodPost, tt.path, bytes.NewReader(body))
+		if tt.chunked {
+			// Without a length the body is sent in chunks.
+			req = httptest.NewRequest(fiber.MethodPost, tt.path, io.MultiReader(bytes.NewReader(body)))
+			req.ContentLength, req.TransferEncoding = -1, []string{"chunked"}
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("POST %s with %d bytes: %v", tt.path, tt.size, err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != tt.want {
+			t.Errorf("POST %s with %d bytes (chunked %v) = %d %s, want %d", tt.path, tt.size, tt.chunked, resp.StatusCode, got, tt.want)
+		} else if tt.want == fiber.StatusOK && string(got) != strconv.Itoa(tt.size) {
+			t.Errorf("POST %s with %d bytes read %s bytes", tt.path, tt.size, got)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/static"
+	"gopkg.in/yaml.v3"
+)
+
+// docRoutes are the routes publishing the documentation of an API version. They are not part of the spec.
+var docRoutes = []string{"openapi.json", "openapi.yaml", "docs", "docs/*"}
+
+// operationMethods are the keys of an OpenAPI path item that describe operations.
+var operationMethods = []string{
+	fiber.MethodGet, fiber.MethodPut, fiber.MethodPost, fiber.MethodDelete,
+	fiber.MethodOptions, fiber.MethodHead, fiber.MethodPatch, fiber.MethodTrace,
+}
+
+// swaggerUI is the Swagger UI page. Its assets are embedded in the binary, so it works offline.
+var swaggerUI = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>{{.Title}}</title>
+  <base href="{{.Base}}">
+  <link rel="stylesheet" type="text/css" href="swagger-ui.css">
+  <link rel="icon" type="image/png" href="favicon-32x32.png" sizes="32x32">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="swagger-ui-bundle.js" charset="UTF-8"></script>
+  <script src="swagger-ui-standalone-preset.js" charset="UTF-8"></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: "../openapi.json",
+      dom_id: "#swagger-ui",
+      deepLinking: true,
+      presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+      layout: "StandaloneLayout"
+    });
+  </script>
+</body>
+</html>
+`))
+
+// mapDocRoutes maps the OpenAPI document of an API version as JSON and YAML, and the Swagger UI showing it.
+func mapDocRoutes(r fiber.Router, version string) {
+	r.Get(`openapi.json`, serveOpenAPI(version))
+	r.Get(`openapi.yaml`, serveOpenAPIYAML(version))
+	r.Get(`docs`, serveSwaggerUI(version))
+	// Each group needs its own static handler, as it strips the prefix of the first route it serves.
+	r.Get(`docs/*`, static.New("", static.Config{FS: swaggerFiles.FS}))
+}
+
+// serveOpenAPIYAML serves the OpenAPI document of an API version as YAML.
+func serveOpenAPIYAML(version string) fiber.Handler {
+	doc, err := openAPIDocument(version)
+	if err == nil {
+		var spec map[string]any
+		if err = json.Unmarshal(doc, &spec); err == nil {
+			doc, err = yaml.Marshal(spec)
+		}
+	}
+	return func(ctx fiber.Ctx) error {
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		ctx.Set(fiber.HeaderContentType, "application/yaml")
+		return ctx.Status(fiber.StatusOK).Send(doc)
+	}
+}
+
+// serveSwaggerUI serves the Swagger UI page of an API version. Assets and the document are
+// referenced relative to the page, so it works under any prefix.
+func serveSwaggerUI(version string) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return swaggerUI.Execute(ctx.Status(fiber.StatusOK), map[string]string{
+			"Title": "Music Collection " + version,
+			"Base":  strings.TrimSuffix(ctx.Path(), "/") + "/",
+		})
+	}
+}
+
+// CheckRoutes compares the routes registered under prefix with the operations in the OpenAPI
+// document of the API version. It reports every operation that is missing on either side, so the
+// spec cannot silently drift from MapRoutes. Paths with their own servers, like GraphQL, are
+// served outside the version prefix and are skipped.
+func CheckRoutes(routes []fiber.Route, prefix, version string) error {
+	doc, err := openAPIDocument(version)
+	if err != nil {
+		return err
+	}
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err = json.Unmarshal(doc, &spec); err != nil {
+		return fmt.Errorf("decoding OpenAPI spec: %v", err)
+	}
+
+	documented := map[string]bool{}
+	for path, item := range spec.Paths {
+		if _, ok := item["servers"]; ok {
+			continue
+		}
+		for method := range item {
+			if method = strings.ToUpper(method); slices.Contains(operationMethods, method) {
+				documented[method+" "+path] = true
+			}
+		}
+	}
+
+	registered := map[string]bool{}
+	for _, route := range routes {
+		path, ok := strings.CutPrefix(route.Path, prefix+"/")
+		if !ok || route.Method == fiber.MethodHead || slices.Contains(docRoutes, path) {
+			continue
+		}
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			if name, ok := strings.CutPrefix(segment, ":"); ok {
+				segments[i] = "{" + name + "}"
+			}
+		}
+		registered[route.Method+" /"+strings.Join(segments, "/")] = true
+	}
+
+	var mismatches []string
+	for operation := range registered {
+		if !documented[operation] {
+			mismatches = append(mismatches, operation+" is not in the spec")
+		}
+	}
+	for operation := range documented {
+		if !registered[operation] {
+			mismatches = append(mismatches, operation+" is not routed")
+		}
+	}
+	if len(mismatches) > 0 {
+		slices.Sort(mismatches)
+		return fmt.Errorf("routes of %s do not match the OpenAPI spec: %s", version, strings.Join(mismatches, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"effectiveMobile/internal"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+var errPreconditionFailed = errors.New("If-Match does not name a current song version")
+
+// songETag renders the strong validator of a song version in a media type. Every representation
+// needs its own tag, so JSON keeps the bare version and the others add their subtype, e.g. "3-xml".
+func songETag(version int64, mediaType string) string {
+	tag := strconv.FormatInt(version, 10)
+	if mediaType != fiber.MIMEApplicationJSON {
+		_, subtype, _ := strings.Cut(mediaType, "/")
+		tag += "-" + subtype
+	}
+	return strconv.Quote(tag)
+}
+
+// parseIfMatch returns the song version required by If-Match, or nil when any version will do.
+// If-Match uses strong comparison, so weak and malformed tags can never match. The tag of any
+// representation names its version.
+func parseIfMatch(ctx fiber.Ctx) (*int64, error) {
+	raw := strings.TrimSpace(ctx.Get(fiber.HeaderIfMatch))
+	if raw == "" || raw == "*" {
+		return nil, nil
+	}
+	tag, err := strconv.Unquote(raw)
+	if err != nil || strings.HasPrefix(raw, "W/") {
+		return nil, errPreconditionFailed
+	}
+	tag, _, _ = strings.Cut(tag, "-")
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil {
+		return nil, errPreconditionFailed
+	}
+	return &version, nil
+}
+
+// writeSong answers with v, a song at version, in the media type negotiated from Accept, tagged
+// with the validator of that representation. A read whose If-None-Match names the tag gets 304
+// instead; If-None-Match uses weak comparison.
+func (h Handler) writeSong(ctx fiber.Ctx, status int, version int64, v any) error {
+	mediaType := h.negotiate(ctx, v)
+	if mediaType == "" {
+		return h.notAcceptable(ctx, v)
+	}
+	etag := songETag(version, mediaType)
+	ctx.Set(fiber.HeaderETag, etag)
+	if ctx.Method() == fiber.MethodGet || ctx.Method() == fiber.MethodHead {
+		for _, tag := range strings.Split(ctx.Get(fiber.HeaderIfNoneMatch), ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return ctx.SendStatus(fiber.StatusNotModified)
+			}
+		}
+	}
+	return h.send(ctx, status, mediaType, v)
+}
+
+// songReadError maps a failed song lookup to its response.
+func (h Handler) songReadError(ctx fiber.Ctx, err error) error {
+	if errors.Is(err, internal.ErrSongNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Song not found"})
+	}
+	h.logger.Errorf("Failed to get song: %v", err)
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+}
+
+// songWriteError maps a failed conditional write to its response.
+func (h Handler) songWriteError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, internal.ErrSongNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Song not found"})
+	case errors.Is(err, internal.ErrVersionMismatch):
+		return ctx.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"error": "Song was modified, fetch it again and retry"})
+	}
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+}
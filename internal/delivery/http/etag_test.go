@@ -0,0 +1,135 @@
+package http
+
+import (
+	"effectiveMobile/config"
+	"effectiveMobile/internal"
+	"effectiveMobile/pkg/encoder"
+	"effectiveMobile/pkg/logger"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestSongETag(t *testing.T) {
+	tests := []struct {
+		mediaType, want string
+	}{
+		{mediaType: fiber.MIMEApplicationJSON, want: `"3"`},
+		{mediaType: fiber.MIMEApplicationXML, want: `"3-xml"`},
+		{mediaType: "application/msgpack", want: `"3-msgpack"`},
+		{mediaType: "application/x-msgpack", want: `"3-x-msgpack"`},
+	}
+	for _, tt := range tests {
+		if got := songETag(3, tt.mediaType); got != tt.want {
+			t.Errorf("songETag(3, %s) = %s, want %s", tt.mediaType, got, tt.want)
+		}
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	app := fiber.New()
+	app.Patch("/", func(ctx fiber.Ctx) error {
+		version, err := parseIfMatch(ctx)
+		switch {
+		case err != nil:
+			return ctx.SendStatus(fiber.StatusPreconditionFailed)
+		case version == nil:
+			return ctx.SendString("any")
+		}
+		return ctx.SendString(strconv.FormatInt(*version, 10))
+	})
+
+	tests := []struct {
+		header, want string
+	}{
+		{header: "", want: "any"},
+		{header: "*", want: "any"},
+		{header: `"3"`, want: "3"},
+		{header: `"3-xml"`, want: "3"},
+		{header: `"3-x-msgpack"`, want: "3"},
+		{header: `W/"3"`},
+		{header: `3`},
+		{header: `"three"`},
+		{header: `"-xml"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodPatch, "/", nil)
+		req.Header.Set(fiber.HeaderIfMatch, tt.header)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("app.Test error = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if tt.want == "" && resp.StatusCode != fiber.StatusPreconditionFailed || tt.want != "" && string(body) != tt.want {
+			t.Errorf("If-Match %s = %d %s, want %q", tt.header, resp.StatusCode, body, tt.want)
+		}
+	}
+}
+
+func TestWriteSong(t *testing.T) {
+	l := logger.NewApiLogger(&config.Config{})
+	_ = l.InitLogger()
+	encoders := encoder.NewRegistry().
+		Register(fiber.MIMEApplicationJSON, encoder.MarshalFunc(json.Marshal)).
+		Register(fiber.MIMEApplicationXML, encoder.XML).
+		Register("text/csv", encoder.CSV{}).
+		Register("application/msgpack", encoder.MessagePack{})
+	h := NewHandler(nil, &config.Config{}, l, encoders)
+	song := &internal.SongResource{Id: "u1", Group: "Muse", Song: "Uprising"}
+
+	app := fiber.New()
+	app.Get("/", func(ctx fiber.Ctx) error {
+		return h.writeSong(ctx, fiber.StatusOK, 3, song)
+	})
+	app.Patch("/", func(ctx fiber.Ctx) error {
+		return h.writeSong(ctx, fiber.StatusOK, 3, song)
+	})
+
+	tests := []struct {
+		method, accept, ifNoneMatch string
+		status                      int
+		contentType, etag           string
+	}{
+		{method: fiber.MethodGet, status: fiber.StatusOK, contentType: fiber.MIMEApplicationJSON, etag: `"3"`},
+		{method: fiber.MethodGet, accept: "application/xml", status: fiber.StatusOK, contentType: fiber.MIMEApplicationXML, etag: `"3-xml"`},
+		{method: fiber.MethodGet, accept: "application/msgpack", status: fiber.StatusOK, contentType: "application/msgpack", etag: `"3-msgpack"`},
+		{method: fiber.MethodGet, accept: "text/csv", status: fiber.StatusNotAcceptable},
+		{method: fiber.MethodGet, ifNoneMatch: `W/"3"`, status: fiber.StatusNotModified, etag: `"3"`},
+		// The tag of the JSON representation does not validate the XML one.
+		{method: fiber.MethodGet, accept: "application/xml", ifNoneMatch: `"3"`, status: fiber.StatusOK, contentType: fiber.MIMEApplicationXML, etag: `"3-xml"`},
+		{method: fiber.MethodGet, accept: "application/xml", ifNoneMatch: `"2-xml", "3-xml"`, status: fiber.StatusNotModified, etag: `"3-xml"`},
+		{method: fiber.MethodPatch, accept: "application/xml", ifNoneMatch: "*", status: fiber.StatusOK, contentType: fiber.MIMEApplicationXML, etag: `"3-xml"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/", nil)
+		if tt.accept != "" {
+			req.Header.Set(fiber.HeaderAccept, tt.accept)
+		}
+		if tt.ifNoneMatch != "" {
+			req.Header.Set(fiber.HeaderIfNoneMatch, tt.ifNoneMatch)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("app.Test error = %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s Accept %q If-None-Match %q = %d, want %d", tt.method, tt.accept, tt.ifNoneMatch, resp.StatusCode, tt.status)
+			continue
+		}
+		if tt.status == fiber.StatusNotAcceptable {
+			continue
+		}
+		if got := resp.Header.Get(fiber.HeaderETag); got != tt.etag {
+			t.Errorf("%s Accept %q ETag = %s, want %s", tt.method, tt.accept, got, tt.etag)
+		}
+		if got := resp.Header.Get(fiber.HeaderContentType); tt.contentType != "" && got != tt.contentType {
+			t.Errorf("%s Accept %q Content-Type = %s, want %s", tt.method, tt.accept, got, tt.contentType)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"bufio"
+	"effectiveMobile/internal"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v3"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	headerLastEventID = "Last-Event-ID"
+
+	// eventRetry is how long EventSource clients wait before reconnecting, in milliseconds.
+	eventRetry = 3000
+	// eventWriteTimeout bounds how long a WebSocket client may take to accept a message.
+	eventWriteTimeout = 10 * time.Second
+)
+
+// eventUpgrader accepts WebSocket clients from any origin: the feed is as public as the catalog.
+var eventUpgrader = websocket.FastHTTPUpgrader{
+	CheckOrigin: func(ctx *fasthttp.RequestCtx) bool { return true },
+}
+
+// parseEventQuery reads the artist and song filters, which can be repeated, and the event to
+// resume after. The last event is taken from the Last-Event-ID header EventSource sends when it
+// reconnects, or from the lastEventId parameter for the first connection and for WebSockets.
+// The values are copied because the feed outlives the request.
+func parseEventQuery(ctx fiber.Ctx) (internal.EventFilter, int64, error) {
+	var filter internal.EventFilter
+	args := ctx.Context().QueryArgs()
+	for _, group := range args.PeekMulti("group") {
+		filter.Groups = append(filter.Groups, string(group))
+	}
+	for _, ids := range args.PeekMulti("songId") {
+		for _, id := range strings.Split(string(ids), ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				filter.SongIDs = append(filter.SongIDs, id)
+			}
+		}
+	}
+
+	raw := ctx.Get(headerLastEventID)
+	if raw == "" {
+		raw = ctx.Query("lastEventId")
+	}
+	if raw == "" {
+		return filter, 0, nil
+	}
+	lastEventID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || lastEventID < 0 {
+		return filter, 0, fmt.Errorf("%s must be the id of an event received from the feed", headerLastEventID)
+	}
+	return filter, lastEventID, nil
+}
+
+// streamEvents writes the feed as Server-Sent Events. Each event carries its seq as id, so
+// EventSource resumes where it left off by itself. Idle streams get a comment every heartbeat,
+// which also ends the stream once the client is gone.
+func (h Handler) streamEvents(ctx fiber.Ctx, filter internal.EventFilter, lastEventID int64) {
+	marshal := ctx.App().Config().JSONEncoder
+	events, cancel := h.useCase.SubscribeSongEvents(filter, lastEventID)
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	// Keeps reverse proxies like nginx from buffering the stream.
+	ctx.Set("X-Accel-Buffering", "no")
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		heartbeat := time.NewTicker(h.heartbeat)
+		defer heartbeat.Stop()
+
+		fmt.Fprintf(w, "retry: %d\n\n", eventRetry)
+		for {
+			if err := w.Flush(); err != nil {
+				h.logger.Debugf("Event stream closed: %v", err)
+				return
+			}
+			select {
+			case event, ok := <-events:
+				if !ok {
+					// The client fell behind and reconnects from its last event.
+					h.logger.Warn("Event stream dropped: the client fell behind")
+					return
+				}
+				data, err := marshal(event)
+				if err != nil {
+					h.logger.Errorf("Failed to encode song event: %v", err)
+					continue
+				}
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+			case <-heartbeat.C:
+				w.WriteString(": ping\n\n")
+			}
+		}
+	})
+}
+
+// serveEventsWebSocket sends the feed as one JSON text message per event, including its seq to
+// resume from with lastEventId. Idle connections are pinged every heartbeat. A client that falls
+// behind is closed with 1013, try again later.
+func (h Handler) serveEventsWebSocket(ctx fiber.Ctx, filter internal.EventFilter, lastEventID int64) error {
+	marshal := ctx.App().Config().JSONEncoder
+	return eventUpgrader.Upgrade(ctx.Context(), func(conn *websocket.Conn) {
+		defer conn.Close()
+		events, cancel := h.useCase.SubscribeSongEvents(filter, lastEventID)
+		defer cancel()
+
+		// Messages from the client are not expected, but reading handles its pongs and close.
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		heartbeat := time.NewTicker(h.heartbeat)
+		defer heartbeat.Stop()
+		for {
+			var err error
+			select {
+			case event, ok := <-events:
+				if !ok {
+					h.logger.Warn("Event WebSocket dropped: the client fell behind")
+					message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "fell behind, resume from the last event")
+					_ = conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(eventWriteTimeout))
+					return
+				}
+				var data []byte
+				if data, err = marshal(event); err == nil {
+					_ = conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+					err = conn.WriteMessage(websocket.TextMessage, data)
+				}
+			case <-heartbeat.C:
+				err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWriteTimeout))
+			case <-closed:
+				return
+			}
+			if err != nil {
+				h.logger.Debugf("Event WebSocket closed: %v", err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"bufio"
+	"compress/gzip"
+	"effectiveMobile/internal"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/utils/v2"
+)
+
+// exportFormats maps the format parameter to the file extension and content type of the export.
+var exportFormats = map[string]struct{ extension, contentType string }{
+	"json":   {extension: "json", contentType: fiber.MIMEApplicationJSON},
+	"ndjson": {extension: "ndjson", contentType: "application/x-ndjson"},
+	"csv":    {extension: "csv", contentType: "text/csv"},
+}
+
+// parseExportQuery reads the GetSongs filters plus the sort, fields and format of the export.
+// The query is detached from the request buffers because it is used after the handler returns.
+func parseExportQuery(ctx fiber.Ctx) (*internal.SongsQuery, string, error) {
+	filter, err := parseGetSongsQuery(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	filter.Limit, filter.Offset = nil, nil
+	for _, value := range []**string{&filter.Id, &filter.Group, &filter.Song, &filter.ReleaseDate, &filter.Text, &filter.Link} {
+		if *value != nil {
+			detached := utils.CopyString(**value)
+			*value = &detached
+		}
+	}
+	q := &internal.SongsQuery{Filter: *filter}
+
+	if raw := ctx.Query("fuzzy"); raw != "" {
+		if q.Fuzzy, err = strconv.ParseBool(raw); err != nil {
+			return nil, "", fmt.Errorf("fuzzy must be a boolean")
+		}
+	}
+	if q.Sort, err = internal.ParseSort(utils.CopyString(ctx.Query("sort")), internal.SongSortFields); err != nil {
+		return nil, "", err
+	}
+	if q.Fields, err = queryList(ctx, "fields", internal.SongFields); err != nil {
+		return nil, "", err
+	}
+	for i, field := range q.Fields {
+		q.Fields[i] = utils.CopyString(field)
+	}
+
+	format := ctx.Query("format", "json")
+	if _, ok := exportFormats[format]; !ok {
+		return nil, "", fmt.Errorf("format must be json, ndjson or csv")
+	}
+	return q, utils.CopyString(format), nil
+}
+
+// songEncoder writes songs one at a time in an export format.
+type songEncoder interface {
+	encode(view *internal.SongView) error
+	close() error
+}
+
+func newSongEncoder(format string, w io.Writer, fields []string, marshal func(v any) ([]byte, error)) songEncoder {
+	switch format {
+	case "ndjson":
+		return &ndjsonSongEncoder{w: w, marshal: marshal}
+	case "csv":
+		return &csvSongEncoder{w: csv.NewWriter(w), fields: csvFields(fields)}
+	}
+	return &jsonSongEncoder{w: w, marshal: marshal}
+}
+
+// jsonSongEncoder writes a single JSON array.
+type jsonSongEncoder struct {
+	w       io.Writer
+	marshal func(v any) ([]byte, error)
+	started bool
+}
+
+func (e *jsonSongEncoder) encode(view *internal.SongView) error {
+	raw, err := e.marshal(view)
+	if err != nil {
+		return err
+	}
+	lead := ","
+	if !e.started {
+		lead, e.started = "[", true
+	}
+	if _, err = io.WriteString(e.w, lead); err != nil {
+		return err
+	}
+	_, err = e.w.Write(raw)
+	return err
+}
+
+func (e *jsonSongEncoder) close() error {
+	tail := "]"
+	if !e.started {
+		tail = "[]"
+	}
+	_, err := io.WriteString(e.w, tail)
+	return err
+}
+
+// ndjsonSongEncoder writes one JSON object per line.
+type ndjsonSongEncoder struct {
+	w       io.Writer
+	marshal func(v any) ([]byte, error)
+}
+
+func (e *ndjsonSongEncoder) encode(view *internal.SongView) error {
+	raw, err := e.marshal(view)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append(raw, '\n'))
+	return err
+}
+
+func (e *ndjsonSongEncoder) close() error {
+	return nil
+}
+
+// csvSongEncoder writes a header row followed by one row per song.
+type csvSongEncoder struct {
+	w      *csv.Writer
+	fields []string
+	header bool
+}
+
+func (e *csvSongEncoder) encode(view *internal.SongView) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	return e.w.Write(songRecord(view, e.fields))
+}
+
+func (e *csvSongEncoder) close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvSongEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	return e.w.Write(append([]string{"id", "version"}, e.fields...))
+}
+
+func csvValue(view *internal.SongView, field string) string {
+	var value *string
+	switch field {
+	case "group":
+		value = view.Group
+	case "song":
+		value = view.Song
+	case "releaseDate":
+		value = view.ReleaseDate
+	case "text":
+		value = view.Text
+	case "link":
+		value = view.Link
+	}
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// acceptsGzip reports whether the client listed gzip in Accept-Encoding.
+func acceptsGzip(ctx fiber.Ctx) bool {
+	for _, encoding := range strings.Split(ctx.Get(fiber.HeaderAcceptEncoding), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.EqualFold(name, "gzip") || name == "*" {
+			return !slices.Contains([]string{"q=0", "q=0.0"}, strings.ReplaceAll(params, " ", ""))
+		}
+	}
+	return false
+}
+
+// streamExport writes the export as the response body while rows are read from the database.
+// Headers are sent before the first row, so a failure halfway leaves a truncated body that is
+// only reported in the log.
+func (h Handler) streamExport(ctx fiber.Ctx, q *internal.SongsQuery, format string) {
+	compress := acceptsGzip(ctx)
+	marshal := ctx.App().Config().JSONEncoder
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var out io.Writer = w
+		var zw *gzip.Writer
+		if compress {
+			zw = gzip.NewWriter(w)
+			out = zw
+		}
+
+		encoder := newSongEncoder(format, out, q.Fields, marshal)
+		err := h.useCase.ExportSongs(q, func(view *internal.SongView) error {
+			return encoder.encode(view)
+		})
+		if err == nil {
+			err = encoder.close()
+		}
+		if zw != nil {
+			if closeErr := zw.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if flushErr := w.Flush(); err == nil {
+			err = flushErr
+		}
+		if err != nil {
+			h.logger.Errorf("Export aborted: %v", err)
+			return
+		}
+		h.logger.Info("Successfully exported songs")
+	})
+	if compress {
+		ctx.Set(fiber.HeaderContentEncoding, "gzip")
+	}
+	ctx.Vary(fiber.HeaderAcceptEncoding)
+}
@@ -1,24 +1,41 @@
 package http
 
 import (
-	useCase "effectiveMobile/internal"
+	"bytes"
+	"effectiveMobile/config"
+	"effectiveMobile/internal"
+	"effectiveMobile/pkg/encoder"
 	"effectiveMobile/pkg/logger"
+	"errors"
+	"io"
+	"time"
+	"github.com/fasthttp/websocket"
 	openapi "github.com/Lineblaze/effective_mobile_gen"
 	"github.com/gofiber/fiber/v3"
 )
 
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 20
+)
+
 //go:generate ifacemaker -f handler.go -o ../../handler.go -i Handler -s Handler -p internal -y "Controller describes methods, implemented by the http package."
 type Handler struct {
-	useCase useCase.UseCase
-	logger  *logger.ApiLogger
+	useCase    internal.UseCase
+	logger     *logger.ApiLogger
+	sunset     time.Time
+	adminToken string
+	heartbeat  time.Duration
+	encoders   *encoder.Registry
 }
 
-func NewHandler(useCase useCase.UseCase, logger *logger.ApiLogger) *Handler {
-	return &Handler{useCase: useCase, logger: logger}
+func NewHandler(useCase internal.UseCase, cfg *config.Config, logger *logger.ApiLogger, encoders *encoder.Registry) *Handler {
+	return &Handler{useCase: useCase, logger: logger, sunset: cfg.Versions.Sunset, adminToken: cfg.Admin.Token, heartbeat: cfg.Events.Heartbeat, encoders: encoders}
 }
 
 func (h *Handler) GetSongDetail() fiber.Handler {
 	return func(c fiber.Ctx) error {
+		h := h.bind(c)
 		group := c.Query("group")
 		if group == "" {
 			h.logger.Debug("group query param is missing")
@@ -37,62 +54,213 @@ func (h *Handler) GetSongDetail() fiber.Handler {
 		}
 
 		h.logger.Infof("Successfully fetched song detail for group: %s, song: %s", group, song)
-		return c.Status(fiber.StatusOK).JSON(songDetail)
+		return h.respond(c, fiber.StatusOK, internal.NewSongDetailResource(songDetail))
 	}
 }
 
 func (h Handler) GetSongs() fiber.Handler {
 	return func(ctx fiber.Ctx) error {
-		var body openapi.GetSongsBody
-		if err := ctx.Bind().Body(&body); err != nil {
-			h.logger.Debug("Failed to parse GetSongs request body")
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		h := h.bind(ctx)
+		if useBodyFallback(ctx) {
+			return h.getSongsLegacy(ctx)
+		}
+		return h.listSongs(ctx)
+	}
+}
+
+func (h Handler) listSongs(ctx fiber.Ctx) error {
+	h = h.bind(ctx)
+	q, err := parseSongsQuery(ctx)
+	if err != nil {
+		h.logger.Debugf("Failed to parse GetSongs query parameters: %v", err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	h.logger.Infof("Fetching songs with filter: %+v", q.Filter)
+	page, err := h.useCase.GetSongs(q)
+	if err != nil {
+		h.logger.Errorf("Failed to get songs %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+	}
+
+	setPageLinks(ctx, page.NextCursor, page.PrevCursor)
+	h.logger.Infof("Successfully fetched songs, count: %d", len(page.Items))
+	return h.respond(ctx, fiber.StatusOK, songListing{SongsPage: page, fields: q.Fields})
+}
+
+// getSongsLegacy serves the deprecated JSON body form of GetSongs with offset paging and a bare array response.
+func (h Handler) getSongsLegacy(ctx fiber.Ctx) error {
+	h.logger.Warn("GetSongs called with deprecated JSON body, use query parameters instead")
+	h.deprecate(ctx)
+
+	var body openapi.GetSongsBody
+	if err := ctx.Bind().Body(&body); err != nil {
+		h.logger.Debug("Failed to parse GetSongs request body")
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	h.logger.Infof("Fetching songs with body: %+v", body)
+	page, err := h.useCase.GetSongs(&internal.SongsQuery{Filter: body, Unpaged: true})
+	if err != nil {
+		h.logger.Errorf("Failed to get songs %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+	}
+
+	h.logger.Infof("Successfully fetched songs, count: %d", len(page.Items))
+	return ctx.Status(fiber.StatusOK).JSON(page.Items)
+}
+
+// GetSong returns the song with the ID, tagged with its version so the ETag can be sent back in If-Match.
+func (h Handler) GetSong() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		songID := ctx.Params("songId")
+		fields, include, err := parseProjection(ctx, nil)
+		if err != nil {
+			h.logger.Debugf("Failed to parse GetSong query parameters: %v", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		h.logger.Infof("Fetching songs with body: %+v", body)
-		songs, err := h.useCase.GetSongs(&body)
+		h.logger.Infof("Fetching song with ID: %s", songID)
+		song, err := h.useCase.GetSong(songID, fields, include)
 		if err != nil {
-			h.logger.Errorf("Failed to get songs %v", err)
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+			return h.songReadError(ctx, err)
 		}
 
-		h.logger.Infof("Successfully fetched songs, count: %d", len(songs))
-		return ctx.Status(fiber.StatusOK).JSON(songs)
+		h.logger.Infof("Successfully fetched song with ID: %s", songID)
+		return h.writeSong(ctx, fiber.StatusOK, song.Version, song)
 	}
 }
 
-func (h *Handler) GetSongText() fiber.Handler {
+// GetSongBySlug returns the song addressed by artist and title slugs. Former slugs of a renamed
+// song redirect permanently to the current ones.
+func (h Handler) GetSongBySlug() fiber.Handler {
 	return func(ctx fiber.Ctx) error {
-		var body openapi.GetSongTextBody
-		if err := ctx.Bind().Body(&body); err != nil {
-			h.logger.Debug("Failed to parse GetSongText request body")
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		h := h.bind(ctx)
+		artistSlug, songSlug, err := slugParams(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Song not found"})
 		}
-		if body.Group == "" || body.Song == "" {
-			h.logger.Debug("Missing group or song in GetSongText request")
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "group and song are required"})
+		fields, include, err := parseProjection(ctx, nil)
+		if err != nil {
+			h.logger.Debugf("Failed to parse GetSongBySlug query parameters: %v", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		h.logger.Infof("Fetching text for group: %s, song: %s", body.Group, body.Song)
-		verses, err := h.useCase.GetSongText(&body)
+		h.logger.Infof("Fetching song with slug: %s/%s", artistSlug, songSlug)
+		song, err := h.useCase.GetSongBySlug(artistSlug, songSlug, fields, include)
 		if err != nil {
-			h.logger.Errorf("Failed to get song verses: %v", err)
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+			return h.songReadError(ctx, err)
+		}
+		if song.Slug.Artist != artistSlug || song.Slug.Song != songSlug {
+			h.logger.Infof("Redirecting former slug %s/%s of song with ID: %s", artistSlug, songSlug, song.Id)
+			return ctx.Redirect().Status(fiber.StatusMovedPermanently).To(slugLocation(ctx, song.Slug))
 		}
 
-		h.logger.Infof("Successfully fetched verses for group: %s, song: %s", body.Group, body.Song)
-		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"verses": verses})
+		h.logger.Infof("Successfully fetched song with ID: %s", song.Id)
+		return h.writeSong(ctx, fiber.StatusOK, song.Version, song)
+	}
+}
+
+func (h Handler) ExportSongs() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		q, format, err := parseExportQuery(ctx)
+		if err != nil {
+			h.logger.Debugf("Failed to parse ExportSongs query parameters: %v", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		h.logger.Infof("Exporting songs as %s with filter: %+v", format, q.Filter)
+		ctx.Attachment("songs." + exportFormats[format].extension)
+		ctx.Set(fiber.HeaderContentType, exportFormats[format].contentType)
+		h.streamExport(ctx, q, format)
+		return nil
+	}
+}
+
+func (h *Handler) GetSongText() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		if ctx.Method() == fiber.MethodPost {
+			h.deprecate(ctx)
+		}
+		if !useBodyFallback(ctx) {
+			return h.songText(ctx)
+		}
+
+		h.logger.Warn("GetSongText called with deprecated JSON body, use query parameters instead")
+		h.deprecate(ctx)
+		body := &openapi.GetSongTextBody{}
+		if err := ctx.Bind().Body(body); err != nil {
+			h.logger.Debug("Failed to parse GetSongText request body")
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+		return h.writeSongText(ctx, &internal.TextQuery{Group: body.Group, Song: body.Song, Offset: body.Offset, Limit: body.Limit})
+	}
+}
+
+// GetSongTextByID returns the lyrics of the song with the ID, with the same selection as GetSongText.
+func (h Handler) GetSongTextByID() fiber.Handler {
+	return h.songText
+}
+
+func (h Handler) songText(ctx fiber.Ctx) error {
+	h = h.bind(ctx)
+	q, err := parseTextQuery(ctx)
+	if err != nil {
+		h.logger.Debugf("Failed to parse GetSongText query parameters: %v", err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return h.writeSongText(ctx, q)
+}
+
+func (h Handler) writeSongText(ctx fiber.Ctx, q *internal.TextQuery) error {
+	if q.SongID == "" && (q.Group == "" || q.Song == "") {
+		h.logger.Debug("Missing group or song in GetSongText request")
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "group and song are required"})
+	}
+	format, err := lyricsFormat(ctx)
+	if errors.Is(err, errLyricsNotAcceptable) {
+		return ctx.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	h.logger.Infof("Fetching text for song ID: %s, group: %s, song: %s", q.SongID, q.Group, q.Song)
+	text, err := h.useCase.GetSongText(q)
+	if errors.Is(err, internal.ErrSongNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Song not found"})
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to get song verses: %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
 	}
+
+	h.logger.Infof("Successfully fetched %d of %d verses", len(text.Verses), text.TotalVerses)
+	return writeLyrics(ctx, format, text)
+}
+
+// Idempotent replays the stored response of writes repeated with the same Idempotency-Key.
+func (h Handler) Idempotent() fiber.Handler {
+	return h.idempotent
 }
 
 func (h Handler) CreateSong() fiber.Handler {
 	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
 		var req openapi.CreateSongBody
 		if err := ctx.Bind().Body(&req); err != nil {
 			h.logger.Debug("Failed to parse CreateSong request body")
 			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 		}
 
+		// Accept is checked before the song is created, so a client refusing every media type changes nothing.
+		if h.negotiate(ctx, &internal.SongResource{}) == "" {
+			return h.notAcceptable(ctx, &internal.SongResource{})
+		}
+
 		h.logger.Infof("Creating song for group: %s, song: %s", req.Group, req.Song)
 		songDetail, err := h.useCase.FetchSongDetail(req.Group, req.Song)
 		if err != nil {
@@ -106,12 +274,13 @@ func (h Handler) CreateSong() fiber.Handler {
 		}
 
 		h.logger.Infof("Successfully created song for group: %s, song: %s", req.Group, req.Song)
-		return ctx.Status(fiber.StatusOK).JSON(createdSong)
+		return h.writeSong(ctx, fiber.StatusOK, createdSong.Version, internal.NewSongResource(createdSong.Song))
 	}
 }
 
 func (h Handler) UpdateSong() fiber.Handler {
 	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
 		songID := ctx.Params("songId")
 		var req openapi.UpdateSongBody
 		h.logger.Debug("Failed to parse UpdateSong request body")
@@ -119,30 +288,374 @@ func (h Handler) UpdateSong() fiber.Handler {
 			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 		}
 
+		ifVersion, err := parseIfMatch(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if h.negotiate(ctx, &internal.SongResource{}) == "" {
+			return h.notAcceptable(ctx, &internal.SongResource{})
+		}
+
 		h.logger.Infof("Updating song with ID: %s", songID)
-		updatedSong, err := h.useCase.UpdateSong(songID, &req)
+		updatedSong, err := h.useCase.UpdateSong(songID, &req, ifVersion)
 		if err != nil {
 			h.logger.Errorf("Failed to update song: %v", err)
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+			return h.songWriteError(ctx, err)
 		}
 
 		h.logger.Infof("Successfully updated song with ID: %s", songID)
-		return ctx.Status(fiber.StatusOK).JSON(updatedSong)
+		return h.writeSong(ctx, fiber.StatusOK, updatedSong.Version, internal.NewSongResource(updatedSong.Song))
 	}
 }
 
 func (h Handler) DeleteSong() fiber.Handler {
 	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
 		songID := ctx.Params("songId")
+		ifVersion, err := parseIfMatch(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"error": err.Error()})
+		}
 
 		h.logger.Infof("Deleting song with ID: %s", songID)
-		err := h.useCase.DeleteSong(songID)
+		err = h.useCase.DeleteSong(songID, ifVersion)
 		if err != nil {
 			h.logger.Errorf("Failed to delete song: %v", err)
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+			return h.songWriteError(ctx, err)
 		}
 
 		h.logger.Infof("Successfully deleted song with ID: %s", songID)
 		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Song deleted successfully"})
 	}
 }
+
+func (h Handler) CreateSongs() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		body, err := bindBatch[openapi.CreateSongBody](ctx)
+		if err != nil {
+			h.logger.Debug("Failed to parse CreateSongs request body")
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+
+		h.logger.Infof("Creating batch of %d songs", len(body.Items))
+		response, err := h.useCase.CreateSongs(body.Mode, body.Items)
+		return h.writeBatch(ctx, response, err)
+	}
+}
+
+func (h Handler) UpdateSongs() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		body, err := bindBatch[internal.SongUpdate](ctx)
+		if err != nil {
+			h.logger.Debug("Failed to parse UpdateSongs request body")
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+
+		h.logger.Infof("Updating batch of %d songs", len(body.Items))
+		response, err := h.useCase.UpdateSongs(body.Mode, body.Items)
+		return h.writeBatch(ctx, response, err)
+	}
+}
+
+func (h Handler) DeleteSongs() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		body, err := bindBatch[internal.SongDelete](ctx)
+		if err != nil {
+			h.logger.Debug("Failed to parse DeleteSongs request body")
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+
+		h.logger.Infof("Deleting batch of %d songs", len(body.Items))
+		response, err := h.useCase.DeleteSongs(body.Mode, body.Items)
+		return h.writeBatch(ctx, response, err)
+	}
+}
+
+func (h Handler) ImportSongs() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		opts, err := parseImportOptions(ctx)
+		if err != nil {
+			h.logger.Debugf("Failed to parse ImportSongs query parameters: %v", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		source, err := importSource(ctx, opts)
+		if err != nil {
+			h.logger.Debugf("Failed to read ImportSongs upload: %v", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if closer, ok := source.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		h.logger.Infof("Importing songs from %s, dry run: %t", opts.Format, opts.DryRun)
+		report, err := h.useCase.ImportSongs(source, *opts)
+		if errors.Is(err, internal.ErrInvalidImport) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			h.logger.Errorf("Failed to import songs: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+
+		h.logger.Infof("Successfully imported songs, import ID: %s", report.ID)
+		return ctx.Status(fiber.StatusOK).JSON(importResponse{ImportReport: report, ErrorsURL: "/imports/" + report.ID + "/errors"})
+	}
+}
+
+func (h Handler) GetImportReport() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		importID := ctx.Params("importId")
+
+		h.logger.Infof("Fetching import report with ID: %s", importID)
+		report, err := h.useCase.GetImportReport(importID)
+		if errors.Is(err, internal.ErrImportNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Import not found"})
+		}
+		if err != nil {
+			h.logger.Errorf("Failed to get import report: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(importResponse{ImportReport: report, ErrorsURL: "/imports/" + report.ID + "/errors"})
+	}
+}
+
+// GetImportErrors downloads the row errors of an import as CSV.
+func (h Handler) GetImportErrors() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		importID := ctx.Params("importId")
+
+		h.logger.Infof("Fetching import errors with ID: %s", importID)
+		report, err := h.useCase.GetImportReport(importID)
+		if errors.Is(err, internal.ErrImportNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Import not found"})
+		}
+		if err != nil {
+			h.logger.Errorf("Failed to get import report: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+
+		var out bytes.Buffer
+		if err = report.WriteErrorsCSV(&out); err != nil {
+			h.logger.Errorf("Failed to write import errors: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+		ctx.Attachment("import-" + report.ID + "-errors.csv")
+		ctx.Set(fiber.HeaderContentType, "text/csv")
+		return ctx.Status(fiber.StatusOK).Send(out.Bytes())
+	}
+}
+
+func (h *Handler) SearchSongs() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		q, err := parseSearchQuery(ctx)
+		if err != nil {
+			h.logger.Debugf("Failed to parse SearchSongs query parameters: %v", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		h.logger.Infof("Searching songs for: %s", q.Text)
+		page, err := h.useCase.SearchSongs(q)
+		if err != nil {
+			h.logger.Errorf("Failed to search songs: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+
+		setPageLinks(ctx, page.NextCursor, page.PrevCursor)
+		h.logger.Infof("Successfully searched songs, count: %d", len(page.Items))
+		return h.respond(ctx, fiber.StatusOK, searchListing{SearchPage: page, fields: q.Fields})
+	}
+}
+
+func (h *Handler) Suggest() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		query := ctx.Query("q")
+		if query == "" {
+			h.logger.Debug("q query param is missing")
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "q is required"})
+		}
+		limit, err := queryInt32(ctx, "limit")
+		if err != nil {
+			h.logger.Debugf("Failed to parse Suggest query parameters: %v", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		n := defaultSuggestLimit
+		if limit != nil {
+			n = min(int(*limit), maxSuggestLimit)
+		}
+
+		suggestions := h.useCase.Suggest(query, n)
+		h.logger.Infof("Successfully suggested %d names for: %s", len(suggestions), query)
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"suggestions": suggestions})
+	}
+}
+
+// StreamEvents pushes song changes made on any replica as Server-Sent Events.
+func (h Handler) StreamEvents() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		filter, lastEventID, err := parseEventQuery(ctx)
+		if err != nil {
+			h.logger.Debugf("Failed to parse StreamEvents query parameters: %v", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		h.logger.Infof("Streaming song events after %d", lastEventID)
+		h.streamEvents(ctx, filter, lastEventID)
+		return nil
+	}
+}
+
+// StreamEventsWebSocket pushes the same song changes as StreamEvents over a WebSocket.
+func (h Handler) StreamEventsWebSocket() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		filter, lastEventID, err := parseEventQuery(ctx)
+		if err != nil {
+			h.logger.Debugf("Failed to parse StreamEventsWebSocket query parameters: %v", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if !websocket.FastHTTPIsWebSocketUpgrade(ctx.Context()) {
+			ctx.Set(fiber.HeaderUpgrade, "websocket")
+			return ctx.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{"error": "WebSocket upgrade required"})
+		}
+
+		h.logger.Infof("Streaming song events over WebSocket after %d", lastEventID)
+		if err = h.serveEventsWebSocket(ctx, filter, lastEventID); err != nil {
+			h.logger.Debugf("Failed to upgrade to WebSocket: %v", err)
+		}
+		return nil
+	}
+}
+
+// Admin guards the admin API with the ADMIN_TOKEN bearer token.
+func (h Handler) Admin() fiber.Handler {
+	return h.admin
+}
+
+func (h Handler) CreateWebhook() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		var req createWebhookBody
+		if err := ctx.Bind().Body(&req); err != nil {
+			h.logger.Debug("Failed to parse CreateWebhook request body")
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+
+		h.logger.Infof("Creating webhook for URL: %s", req.URL)
+		webhook, err := h.useCase.CreateWebhook(req.URL, req.Events)
+		if errors.Is(err, internal.ErrInvalidWebhook) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			h.logger.Errorf("Failed to create webhook: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+
+		h.logger.Infof("Successfully created webhook with ID: %s", webhook.ID)
+		return ctx.Status(fiber.StatusOK).JSON(webhook)
+	}
+}
+
+func (h Handler) GetWebhooks() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		webhooks, err := h.useCase.GetWebhooks()
+		if err != nil {
+			h.logger.Errorf("Failed to get webhooks: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+
+		h.logger.Infof("Successfully fetched %d webhooks", len(webhooks))
+		return ctx.Status(fiber.StatusOK).JSON(webhooks)
+	}
+}
+
+func (h Handler) DeleteWebhook() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		webhookID := ctx.Params("webhookId")
+
+		h.logger.Infof("Deleting webhook with ID: %s", webhookID)
+		err := h.useCase.DeleteWebhook(webhookID)
+		if errors.Is(err, internal.ErrWebhookNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Webhook not found"})
+		}
+		if err != nil {
+			h.logger.Errorf("Failed to delete webhook: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+
+		h.logger.Infof("Successfully deleted webhook with ID: %s", webhookID)
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Webhook deleted successfully"})
+	}
+}
+
+// GetWebhookDeliveries lists the latest deliveries of a webhook with the outcome of their last attempt.
+func (h Handler) GetWebhookDeliveries() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		webhookID := ctx.Params("webhookId")
+		status, limit, err := parseDeliveryQuery(ctx)
+		if err != nil {
+			h.logger.Debugf("Failed to parse GetWebhookDeliveries query parameters: %v", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		deliveries, err := h.useCase.GetWebhookDeliveries(webhookID, status, limit)
+		if errors.Is(err, internal.ErrWebhookNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Webhook not found"})
+		}
+		if err != nil {
+			h.logger.Errorf("Failed to get webhook deliveries: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+
+		h.logger.Infof("Successfully fetched %d deliveries of webhook with ID: %s", len(deliveries), webhookID)
+		return ctx.Status(fiber.StatusOK).JSON(deliveries)
+	}
+}
+
+// RedeliverWebhook schedules a delivery, typically a dead letter, to be sent again.
+func (h Handler) RedeliverWebhook() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		webhookID, deliveryID := ctx.Params("webhookId"), ctx.Params("deliveryId")
+
+		h.logger.Infof("Redelivering webhook delivery with ID: %s", deliveryID)
+		delivery, err := h.useCase.RedeliverWebhook(webhookID, deliveryID)
+		if errors.Is(err, internal.ErrDeliveryNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Delivery not found"})
+		}
+		if err != nil {
+			h.logger.Errorf("Failed to redeliver webhook delivery: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(delivery)
+	}
+}
+
+// GetOutboxStats reports the backlog of the outbox and how the relay is doing.
+func (h Handler) GetOutboxStats() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		h := h.bind(ctx)
+		stats, err := h.useCase.GetOutboxStats()
+		if err != nil {
+			h.logger.Errorf("Failed to get outbox stats: %v", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(stats)
+	}
+}
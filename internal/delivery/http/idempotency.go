@@ -0,0 +1,82 @@
+package http
+
+import (
+	"crypto/sha256"
+	"effectiveMobile/internal"
+	"encoding/hex"
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored with an idempotent response and sent again on replay.
+var replayedHeaders = []string{fiber.HeaderContentType, fiber.HeaderETag, fiber.HeaderLocation, fiber.HeaderLink}
+
+// idempotent lets clients retry writes safely. The first request with an Idempotency-Key is
+// processed and its response stored; repeats within the TTL get the stored response without the
+// write, or the song detail lookup, happening again. Server errors are not stored, so a request
+// that failed that way is processed again when retried.
+func (h Handler) idempotent(ctx fiber.Ctx) error {
+	h = h.bind(ctx)
+	key := ctx.Get(headerIdempotencyKey)
+	if key == "" {
+		return ctx.Next()
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Idempotency-Key must not be longer than 255 characters"})
+	}
+
+	stored, err := h.useCase.BeginIdempotent(key, requestFingerprint(ctx))
+	switch {
+	case errors.Is(err, internal.ErrIdempotencyKeyInUse):
+		ctx.Set(fiber.HeaderRetryAfter, "1")
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, internal.ErrIdempotencyKeyMismatch):
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		h.logger.Errorf("Failed to begin idempotent request: %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+	case stored != nil:
+		for name, value := range stored.Header {
+			ctx.Set(name, value)
+		}
+		ctx.Set(headerIdempotentReplayed, "true")
+		return ctx.Status(stored.Status).Send(stored.Body)
+	}
+
+	if err = ctx.Next(); err != nil || ctx.Response().StatusCode() >= fiber.StatusInternalServerError {
+		if releaseErr := h.useCase.ReleaseIdempotent(key); releaseErr != nil {
+			h.logger.Errorf("Failed to release idempotency key: %v", releaseErr)
+		}
+		return err
+	}
+
+	resp := ctx.Response()
+	stored = &internal.IdempotentResponse{Status: resp.StatusCode(), Header: map[string]string{}, Body: resp.Body()}
+	for _, name := range replayedHeaders {
+		if value := resp.Header.Peek(name); len(value) > 0 {
+			stored.Header[name] = string(value)
+		}
+	}
+	if err = h.useCase.CompleteIdempotent(key, stored); err != nil {
+		// The response is sent anyway; without a stored response the key is claimable again after the lock timeout.
+		h.logger.Errorf("Failed to store idempotent response: %v", err)
+	}
+	return nil
+}
+
+// requestFingerprint identifies a request by method, path and body, so a key reused for another
+// request is told apart from a retry.
+func requestFingerprint(ctx fiber.Ctx) string {
+	sum := sha256.New()
+	sum.Write([]byte(ctx.Method() + " " + ctx.Path() + "\n"))
+	sum.Write(ctx.Body())
+	return hex.EncodeToString(sum.Sum(nil))
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"context"
+	"effectiveMobile/config"
+	"effectiveMobile/internal"
+	"effectiveMobile/pkg/logger"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// idempotencyUseCase keeps idempotency keys in memory. Methods the tests do not need panic
+// through the nil embedded UseCase.
+type idempotencyUseCase struct {
+	internal.UseCase
+	records map[string]*internal.IdempotencyRecord
+}
+
+func (u *idempotencyUseCase) WithContext(context.Context) internal.UseCase {
+	return u
+}
+
+func (u *idempotencyUseCase) BeginIdempotent(key, fingerprint string) (*internal.IdempotentResponse, error) {
+	record, ok := u.records[key]
+	switch {
+	case !ok:
+		u.records[key] = &internal.IdempotencyRecord{Fingerprint: fingerprint}
+		return nil, nil
+	case record.Fingerprint != fingerprint:
+		return nil, internal.ErrIdempotencyKeyMismatch
+	case record.Response == nil:
+		return nil, internal.ErrIdempotencyKeyInUse
+	}
+	return record.Response, nil
+}
+
+func (u *idempotencyUseCase) CompleteIdempotent(key string, resp *internal.IdempotentResponse) error {
+	u.records[key].Response = resp
+	return nil
+}
+
+func (u *idempotencyUseCase) ReleaseIdempotent(key string) error {
+	delete(u.records, key)
+	return nil
+}
+
+// newIdempotencyApp maps an idempotent POST /songs that fails with 500 while fail is set, and
+// counts how often it really runs.
+func newIdempotencyApp(uc *idempotencyUseCase, calls *int, fail *bool) *fiber.App {
+	l := logger.NewApiLogger(&config.Config{})
+	_ = l.InitLogger()
+	h := NewHandler(uc, &config.Config{}, l, nil)
+
+	app := fiber.New()
+	app.Post("/songs", func(ctx fiber.Ctx) error {
+		*calls++
+		if *fail {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+		}
+		ctx.Location("/songs/" + strconv.Itoa(*calls))
+		ctx.Set("X-Not-Replayed", "true")
+		return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"call": *calls})
+	}, h.Idempotent())
+	return app
+}
+
+type idempotencyResult struct {
+	status   int
+	body     string
+	location string
+	replayed string
+	header   string
+}
+
+func postIdempotent(t *testing.T, app *fiber.App, key, body string) idempotencyResult {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/songs", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(headerIdempotencyKey, key)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test error = %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return idempotencyResult{
+		status:   resp.StatusCode,
+		body:     string(data),
+		location: resp.Header.Get(fiber.HeaderLocation),
+		replayed: resp.Header.Get(headerIdempotentReplayed),
+		header:   resp.Header.Get("X-Not-Replayed"),
+	}
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	uc := &idempotencyUseCase{records: map[string]*internal.IdempotencyRecord{}}
+	var calls int
+	var fail bool
+	app := newIdempotencyApp(uc, &calls, &fail)
+
+	first := postIdempotent(t, app, "k1", `{"song":"Uprising"}`)
+	if first.status != fiber.StatusCreated || first.replayed != "" {
+		t.Fatalf("first request = %+v, want a processed 201", first)
+	}
+	again := postIdempotent(t, app, "k1", `{"song":"Uprising"}`)
+	if calls != 1 {
+		t.Fatalf("handler ran %d times for a retry, want once", calls)
+	}
+	if again.status != first.status || again.body != first.body || again.location != first.location || again.replayed != "true" {
+		t.Errorf("retry = %+v, want the stored %+v replayed", again, first)
+	}
+	if again.header != "" {
+		t.Errorf("retry replayed X-Not-Replayed, which is not a stored header")
+	}
+
+	if got := postIdempotent(t, app, "k1", `{"song":"Starlight"}`); got.status != fiber.StatusUnprocessableEntity {
+		t.Errorf("key reused for another body = %d, want 422", got.status)
+	}
+	postIdempotent(t, app, "", `{"song":"Uprising"}`)
+	postIdempotent(t, app, "", `{"song":"Uprising"}`)
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want requests without a key processed every time", calls)
+	}
+}
+
+func TestIdempotentInFlight(t *testing.T) {
+	uc := &idempotencyUseCase{records: map[string]*internal.IdempotencyRecord{}}
+	var calls int
+	var fail bool
+	app := newIdempotencyApp(uc, &calls, &fail)
+	uc.records["k1"] = &internal.IdempotencyRecord{Fingerprint: fingerprint(t, fiber.MethodPost, "/songs", `{}`)}
+
+	req := httptest.NewRequest(fiber.MethodPost, "/songs", strings.NewReader(`{}`))
+	req.Header.Set(headerIdempotencyKey, "k1")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusConflict || resp.Header.Get(fiber.HeaderRetryAfter) == "" || calls != 0 {
+		t.Errorf("repeat of a request in flight = %d, Retry-After %q, %d calls; want 409 with Retry-After and no call",
+			resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter), calls)
+	}
+}
+
+func TestIdempotentServerErrorReleasesKey(t *testing.T) {
+	uc := &idempotencyUseCase{records: map[string]*internal.IdempotencyRecord{}}
+	var calls int
+	fail := true
+	app := newIdempotencyApp(uc, &calls, &fail)
+
+	if got := postIdempotent(t, app, "k1", `{}`); got.status != fiber.StatusInternalServerError {
+		t.Fatalf("failing request = %d, want 500", got.status)
+	}
+	if _, ok := uc.records["k1"]; ok {
+		t.Fatal("key is still held after a server error")
+	}
+	fail = false
+	if got := postIdempotent(t, app, "k1", `{}`); got.status != fiber.StatusCreated || got.replayed != "" || calls != 2 {
+		t.Errorf("retry after a server error = %+v after %d calls, want it processed again", got, calls)
+	}
+}
+
+func TestIdempotentKeyTooLong(t *testing.T) {
+	uc := &idempotencyUseCase{records: map[string]*internal.IdempotencyRecord{}}
+	var calls int
+	var fail bool
+	app := newIdempotencyApp(uc, &calls, &fail)
+
+	if got := postIdempotent(t, app, strings.Repeat("k", maxIdempotencyKeyLength), `{}`); got.status != fiber.StatusCreated {
+		t.Errorf("key of %d characters = %d, want 201", maxIdempotencyKeyLength, got.status)
+	}
+	if got := postIdempotent(t, app, strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`); got.status != fiber.StatusBadRequest {
+		t.Errorf("key of %d characters = %d, want 400", maxIdempotencyKeyLength+1, got.status)
+	}
+}
+
+// fingerprint returns the requestFingerprint of a request.
+func fingerprint(t *testing.T, method, path, body string) string {
+	t.Helper()
+	app := fiber.New()
+	app.All("/*", func(ctx fiber.Ctx) error {
+		return ctx.SendString(requestFingerprint(ctx))
+	})
+	resp, err := app.Test(httptest.NewRequest(method, path, strings.NewReader(body)), -1)
+	if err != nil {
+		t.Fatalf("app.Test error = %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return string(data)
+}
+
+func TestRequestFingerprint(t *testing.T) {
+	base := fingerprint(t, fiber.MethodPost, "/songs", `{"song":"Uprising"}`)
+	if again := fingerprint(t, fiber.MethodPost, "/songs", `{"song":"Uprising"}`); again != base {
+		t.Errorf("fingerprints of the same request differ: %s and %s", base, again)
+	}
+	tests := []struct {
+		name, method, path, body string
+	}{
+		{name: "method", method: fiber.MethodPatch, path: "/songs", body: `{"song":"Uprising"}`},
+		{name: "path", method: fiber.MethodPost, path: "/songs/batch", body: `{"song":"Uprising"}`},
+		{name: "body", method: fiber.MethodPost, path: "/songs", body: `{"song":"Starlight"}`},
+		{name: "empty body", method: fiber.MethodPost, path: "/songs"},
+	}
+	for _, tt := range tests {
+		if got := fingerprint(t, tt.method, tt.path, tt.body); got == base {
+			t.Errorf("fingerprint ignores the %s", tt.name)
+		}
+	}
+}
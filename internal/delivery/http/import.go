@@ -0,0 +1,73 @@
+package http
+
+import (
+	"bytes"
+	"effectiveMobile/internal"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// importResponse is the import report with a link to its row errors as CSV.
+type importResponse struct {
+	*internal.ImportReport
+	ErrorsURL string `json:"errorsUrl"`
+}
+
+// parseImportOptions reads the import options from the query string.
+func parseImportOptions(ctx fiber.Ctx) (*internal.ImportOptions, error) {
+	opts := &internal.ImportOptions{
+		Format:      ctx.Query("format"),
+		OnDuplicate: internal.DuplicatePolicy(ctx.Query("onDuplicate", string(internal.DuplicateSkip))),
+	}
+
+	var err error
+	if opts.Mapping, err = internal.ParseColumnMapping(ctx.Query("map")); err != nil {
+		return nil, err
+	}
+	for key, dest := range map[string]*bool{"dryRun": &opts.DryRun, "enrich": &opts.Enrich} {
+		if raw := ctx.Query(key); raw != "" {
+			if *dest, err = strconv.ParseBool(raw); err != nil {
+				return nil, fmt.Errorf("%s must be a boolean", key)
+			}
+		}
+	}
+	return opts, nil
+}
+
+// importSource returns the uploaded file, sent either as the raw body or as the file field of a
+// multipart form. Without a format parameter the format follows the content type or file extension.
+func importSource(ctx fiber.Ctx, opts *internal.ImportOptions) (io.Reader, error) {
+	var source io.Reader
+	name, contentType := "", ctx.Get(fiber.HeaderContentType)
+	if strings.HasPrefix(contentType, fiber.MIMEMultipartForm) {
+		file, err := ctx.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("multipart uploads need a file field")
+		}
+		f, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("opening upload: %v", err)
+		}
+		source, name, contentType = f, file.Filename, file.Header.Get(fiber.HeaderContentType)
+	} else {
+		source = bytes.NewReader(ctx.Body())
+	}
+
+	if opts.Format == "" {
+		switch {
+		case strings.Contains(contentType, "csv") || path.Ext(name) == ".csv":
+			opts.Format = "csv"
+		case strings.Contains(contentType, "ndjson") || strings.Contains(contentType, "jsonl") || slices.Contains([]string{".ndjson", ".jsonl"}, path.Ext(name)):
+			opts.Format = "ndjson"
+		default:
+			return nil, fmt.Errorf("format must be given as one of %s", strings.Join(internal.ImportFormats, ", "))
+		}
+	}
+	return source, nil
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"effectiveMobile/internal"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// lyricsFormats lists the renderings of lyrics by format parameter, JSON first as the default.
+var lyricsFormats = []struct {
+	name, mediaType string
+	render          func(b *strings.Builder, text *internal.SongText) error
+}{
+	{name: "json", mediaType: fiber.MIMEApplicationJSON},
+	{name: "text", mediaType: "text/plain", render: renderPlainLyrics},
+	{name: "markdown", mediaType: "text/markdown", render: renderMarkdownLyrics},
+	{name: "html", mediaType: "text/html", render: renderHTMLLyrics},
+	{name: "lrc", mediaType: "text/x-lrc", render: renderLRCLyrics},
+}
+
+var (
+	errLyricsNotAcceptable = errors.New("lyrics can be served as application/json, text/plain, text/markdown, text/html or text/x-lrc")
+	errNoTimings           = errors.New("the lyrics have no LRC timings")
+)
+
+// lrcTimings matches the time tags leading a line of LRC lyrics, e.g. [01:02.50], possibly several of them.
+var lrcTimings = regexp.MustCompile(`^(\[\d{1,3}:\d{2}(?:[.:]\d{1,3})?\]\s*)+`)
+
+// markdownSpecial matches the characters Markdown would read as markup.
+var markdownSpecial = regexp.MustCompile("[\\\\`*_{}\\[\\]<>()#+\\-.!|~]")
+
+// lyricsFormat picks the rendering from the format parameter, or else from Accept. It returns the
+// index of the format in lyricsFormats.
+func lyricsFormat(ctx fiber.Ctx) (int, error) {
+	if name := ctx.Query("format"); name != "" {
+		for i, format := range lyricsFormats {
+			if format.name == name {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("format must be json, text, markdown, html or lrc")
+	}
+
+	offers := make([]string, len(lyricsFormats))
+	for i, format := range lyricsFormats {
+		offers[i] = format.mediaType
+	}
+	accepted := ctx.Accepts(offers...)
+	if accepted == "" {
+		return 0, errLyricsNotAcceptable
+	}
+	return slices.Index(offers, accepted), nil
+}
+
+// writeLyrics answers with the lyrics rendered in the format.
+func writeLyrics(ctx fiber.Ctx, format int, text *internal.SongText) error {
+	if lyricsFormats[format].render == nil {
+		return ctx.Status(fiber.StatusOK).JSON(text)
+	}
+
+	var b strings.Builder
+	if err := lyricsFormats[format].render(&b, text); err != nil {
+		return ctx.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{"error": err.Error()})
+	}
+	ctx.Set(fiber.HeaderContentType, lyricsFormats[format].mediaType+"; charset=utf-8")
+	return ctx.Status(fiber.StatusOK).SendString(b.String())
+}
+
+// isChorus reports whether the i-th returned verse is a chorus.
+func isChorus(text *internal.SongText, i int) bool {
+	return slices.Contains(text.Choruses, text.Numbers[i])
+}
+
+// untimed strips the LRC time tags of a line.
+func untimed(line string) string {
+	return lrcTimings.ReplaceAllString(line, "")
+}
+
+// renderPlainLyrics writes the lines of each verse, verses separated by a blank line.
+func renderPlainLyrics(b *strings.Builder, text *internal.SongText) error {
+	for i, lines := range text.Verses {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range lines {
+			b.WriteString(untimed(line) + "\n")
+		}
+	}
+	return nil
+}
+
+// renderMarkdownLyrics writes every verse under a heading naming it a verse or chorus, with hard
+// line breaks. Markup characters in the lyrics are escaped.
+func renderMarkdownLyrics(b *strings.Builder, text *internal.SongText) error {
+	for i, lines := range text.Verses {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if isChorus(text, i) {
+			fmt.Fprintf(b, "### Chorus\n\n")
+		} else {
+			fmt.Fprintf(b, "### Verse %d\n\n", text.Numbers[i])
+		}
+		for j, line := range lines {
+			b.WriteString(markdownSpecial.ReplaceAllString(untimed(line), `\$0`))
+			if j < len(lines)-1 {
+				b.WriteString("\\")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return nil
+}
+
+// renderHTMLLyrics writes a fragment with a paragraph per stanza and a line break between lines.
+// The lyrics are escaped, so no markup of their own gets through.
+func renderHTMLLyrics(b *strings.Builder, text *internal.SongText) error {
+	b.WriteString(`<div class="lyrics">` + "\n")
+	for i, lines := range text.Verses {
+		class := "stanza"
+		if isChorus(text, i) {
+			class += " chorus"
+		}
+		fmt.Fprintf(b, `<p class="%s" data-verse="%d" data-first-line="%d">`, class, text.Numbers[i], text.FirstLines[i])
+		for j, line := range lines {
+			if j > 0 {
+				b.WriteString("<br>\n")
+			}
+			b.WriteString(html.EscapeString(untimed(line)))
+		}
+		b.WriteString("</p>\n")
+	}
+	b.WriteString("</div>\n")
+	return nil
+}
+
+// renderLRCLyrics writes the timed lines with their time tags, verses separated by a blank line.
+// Lines without a time tag cannot be placed and are left out.
+func renderLRCLyrics(b *strings.Builder, text *internal.SongText) error {
+	timed := 0
+	for i, lines := range text.Verses {
+		if i > 0 && timed > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range lines {
+			tags := lrcTimings.FindString(line)
+			if tags == "" {
+				continue
+			}
+			b.WriteString(strings.ReplaceAll(strings.TrimSpace(tags), " ", "") + strings.TrimSpace(line[len(tags):]) + "\n")
+			timed++
+		}
+	}
+	if timed == 0 {
+		return errNoTimings
+	}
+	return nil
+}
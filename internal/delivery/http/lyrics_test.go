@@ -0,0 +1,141 @@
+package http
+
+import (
+	"effectiveMobile/internal"
+	"flag"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests")
+
+// lyricsFixtures are the songs rendered by the golden tests.
+var lyricsFixtures = map[string]*internal.SongText{
+	// Timed lyrics with a chorus, as LRC files are imported.
+	"timed": {
+		Verses: [][]string{
+			{"[00:21.10]Paranoia is in bloom", "[00:25.40] The PR transmissions will resume"},
+			{"[01:02.00][02:10.00]They will not force us", "They will stop degrading us"},
+		},
+		Numbers:    []int{1, 2},
+		FirstLines: []int{1, 3},
+		Choruses:   []int{2},
+	},
+	// Untimed lyrics full of characters that are markup in HTML or Markdown.
+	"markup": {
+		Verses: [][]string{
+			{`<script>alert("x")</script>`, "Rock & roll, 'til the #1 *star* [falls]"},
+			{"Fish & chips > <b>bold</b>"},
+		},
+		Numbers:    []int{3, 4},
+		FirstLines: []int{5, 7},
+	},
+}
+
+func TestLyricsGolden(t *testing.T) {
+	tests := []struct {
+		fixture, format string
+		status          int
+	}{
+		{fixture: "timed", format: "text", status: fiber.StatusOK},
+		{fixture: "timed", format: "markdown", status: fiber.StatusOK},
+		{fixture: "timed", format: "html", status: fiber.StatusOK},
+		{fixture: "timed", format: "lrc", status: fiber.StatusOK},
+		{fixture: "markup", format: "text", status: fiber.StatusOK},
+		{fixture: "markup", format: "markdown", status: fiber.StatusOK},
+		{fixture: "markup", format: "html", status: fiber.StatusOK},
+		{fixture: "markup", format: "lrc", status: fiber.StatusNotAcceptable},
+	}
+
+	app := fiber.New()
+	app.Get("/:fixture", func(ctx fiber.Ctx) error {
+		format, err := lyricsFormat(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+		return writeLyrics(ctx, format, lyricsFixtures[ctx.Params("fixture")])
+	})
+
+	for _, tt := range tests {
+		name := tt.fixture + "." + tt.format
+		t.Run(name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/"+tt.fixture+"?format="+tt.format, nil), -1)
+			if err != nil {
+				t.Fatalf("app.Test error = %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, body)
+			}
+
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err = os.WriteFile(golden, body, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != string(want) {
+				t.Errorf("body =\n%s\nwant (%s)\n%s", body, golden, want)
+			}
+		})
+	}
+}
+
+func TestHTMLLyricsEscaped(t *testing.T) {
+	var b strings.Builder
+	if err := renderHTMLLyrics(&b, lyricsFixtures["markup"]); err != nil {
+		t.Fatal(err)
+	}
+	for _, raw := range []string{"<script>", "<b>", "& "} {
+		if strings.Contains(b.String(), raw) {
+			t.Errorf("HTML lyrics contain unescaped %q:\n%s", raw, b.String())
+		}
+	}
+}
+
+func TestLyricsFormatAccept(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(ctx fiber.Ctx) error {
+		format, err := lyricsFormat(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusNotAcceptable).SendString(err.Error())
+		}
+		return ctx.SendString(lyricsFormats[format].name)
+	})
+
+	tests := []struct {
+		accept, want string
+		status       int
+	}{
+		{accept: "", want: "json", status: fiber.StatusOK},
+		{accept: "text/x-lrc", want: "lrc", status: fiber.StatusOK},
+		{accept: "text/html;q=0.5, text/markdown", want: "markdown", status: fiber.StatusOK},
+		{accept: "image/png", status: fiber.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		if tt.accept != "" {
+			req.Header.Set(fiber.HeaderAccept, tt.accept)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("app.Test error = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || (tt.status == fiber.StatusOK && string(body) != tt.want) {
+			t.Errorf("Accept %q = %d %s, want %d %s", tt.accept, resp.StatusCode, body, tt.status, tt.want)
+		}
+	}
+}
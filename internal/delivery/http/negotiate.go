@@ -0,0 +1,93 @@
+package http
+
+import (
+	"effectiveMobile/internal"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// respond answers with v in the media type negotiated from Accept among the encoders able to
+// represent it, JSON when Accept allows any. None being acceptable gets 406, listing the offers.
+func (h Handler) respond(ctx fiber.Ctx, status int, v any) error {
+	mediaType := h.negotiate(ctx, v)
+	if mediaType == "" {
+		return h.notAcceptable(ctx, v)
+	}
+	return h.send(ctx, status, mediaType, v)
+}
+
+// negotiate returns the media type v is to be served in, or "" when Accept allows none of them.
+func (h Handler) negotiate(ctx fiber.Ctx, v any) string {
+	ctx.Vary(fiber.HeaderAccept)
+	return ctx.Accepts(h.encoders.Offers(v)...)
+}
+
+// notAcceptable answers 406, listing the media types v can be served in.
+func (h Handler) notAcceptable(ctx fiber.Ctx, v any) error {
+	h.logger.Debugf("No acceptable media type for Accept: %s", ctx.Get(fiber.HeaderAccept))
+	offers := h.encoders.Offers(v)
+	return ctx.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{"error": "the response can be served as " + strings.Join(offers, ", ")})
+}
+
+// send answers with v encoded in the media type.
+func (h Handler) send(ctx fiber.Ctx, status int, mediaType string, v any) error {
+	body, err := h.encoders.Marshal(mediaType, v)
+	if err != nil {
+		h.logger.Errorf("Failed to encode response as %s: %v", mediaType, err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "InternalServerError"})
+	}
+	ctx.Set(fiber.HeaderContentType, mediaType)
+	return ctx.Status(status).Send(body)
+}
+
+// songListing is a page of songs that can also be written as a table of the selected fields,
+// with the columns of the CSV export. It encodes as the page otherwise.
+type songListing struct {
+	*internal.SongsPage
+	fields []string
+}
+
+func (l songListing) Table() ([]string, [][]string) {
+	fields := csvFields(l.fields)
+	rows := make([][]string, len(l.Items))
+	for i, view := range l.Items {
+		rows[i] = songRecord(view, fields)
+	}
+	return append([]string{"id", "version"}, fields...), rows
+}
+
+// searchListing is a page of search results that can also be written as a table of the
+// selected fields followed by the rank.
+type searchListing struct {
+	*internal.SearchPage
+	fields []string
+}
+
+func (l searchListing) Table() ([]string, [][]string) {
+	fields := csvFields(l.fields)
+	rows := make([][]string, len(l.Items))
+	for i, hit := range l.Items {
+		rows[i] = append(songRecord(hit.Song, fields), strconv.FormatFloat(float64(hit.Rank), 'g', -1, 32))
+	}
+	return append(append([]string{"id", "version"}, fields...), "rank"), rows
+}
+
+// csvFields returns the columns following id and version for the selected fields; id leads every
+// row already.
+func csvFields(fields []string) []string {
+	if len(fields) == 0 {
+		fields = internal.SongFields
+	}
+	return slices.DeleteFunc(slices.Clone(fields), func(field string) bool { return field == "id" })
+}
+
+func songRecord(view *internal.SongView, fields []string) []string {
+	record := []string{view.Id, strconv.FormatInt(view.Version, 10)}
+	for _, field := range fields {
+		record = append(record, csvValue(view, field))
+	}
+	return record
+}
@@ -0,0 +1,234 @@
+package http
+
+import (
+	"effectiveMobile/internal"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+	"github.com/gofiber/fiber/v3"
+)
+
+// queryString returns the query parameter value, or nil if it is absent.
+func queryString(ctx fiber.Ctx, key string) *string {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// queryInt32 parses an optional non-negative integer query parameter.
+func queryInt32(ctx fiber.Ctx, key string) (*int32, error) {
+	raw := ctx.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer", key)
+	}
+	v := int32(value)
+	return &v, nil
+}
+
+// queryRanges parses an optional comma-separated list of numbers and ranges such as 2-4.
+func queryRanges(ctx fiber.Ctx, key string) ([]internal.NumberRange, error) {
+	raw := ctx.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	ranges, err := internal.ParseRanges(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", key, err)
+	}
+	return ranges, nil
+}
+
+// queryList parses an optional comma-separated list, rejecting entries outside allowed.
+func queryList(ctx fiber.Ctx, key string, allowed []string) ([]string, error) {
+	raw := ctx.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	var list []string
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if !slices.Contains(allowed, item) {
+			return nil, fmt.Errorf("%s: unknown value %q, expected one of %s", key, item, strings.Join(allowed, ", "))
+		}
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list, nil
+}
+
+// parseProjection reads the selected fields and the related resources to include.
+func parseProjection(ctx fiber.Ctx, defaultFields []string) ([]string, []string, error) {
+	fields, err := queryList(ctx, "fields", internal.SongFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fields == nil {
+		fields = defaultFields
+	}
+	include, err := queryList(ctx, "include", internal.SongIncludes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fields, include, nil
+}
+
+// hasQuery reports whether any query parameters were sent with the request.
+func hasQuery(ctx fiber.Ctx) bool {
+	return ctx.Context().QueryArgs().Len() > 0
+}
+
+// useBodyFallback reports whether the request should be parsed from the deprecated JSON body form.
+func useBodyFallback(ctx fiber.Ctx) bool {
+	return !hasQuery(ctx) && len(ctx.Body()) > 0
+}
+
+func parseGetSongsQuery(ctx fiber.Ctx) (*openapi.GetSongsBody, error) {
+	body := &openapi.GetSongsBody{
+		Id:          queryString(ctx, "id"),
+		Group:       queryString(ctx, "group"),
+		Song:        queryString(ctx, "song"),
+		ReleaseDate: queryString(ctx, "releaseDate"),
+		Text:        queryString(ctx, "text"),
+		Link:        queryString(ctx, "link"),
+	}
+
+	var err error
+	if body.Limit, err = queryInt32(ctx, "limit"); err != nil {
+		return nil, err
+	}
+	if body.Offset, err = queryInt32(ctx, "offset"); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// parseTextQuery reads the song, by ID from the path or by name, the selection and the page of lyrics.
+func parseTextQuery(ctx fiber.Ctx) (*internal.TextQuery, error) {
+	q := &internal.TextQuery{
+		SongID: ctx.Params("songId"),
+		Group:  ctx.Query("group"),
+		Song:   ctx.Query("song"),
+	}
+
+	var err error
+	if q.Verses, err = queryRanges(ctx, "verses"); err != nil {
+		return nil, err
+	}
+	if q.Lines, err = queryRanges(ctx, "lines"); err != nil {
+		return nil, err
+	}
+	if raw := ctx.Query("chorus"); raw != "" {
+		if q.Chorus, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("chorus must be a boolean")
+		}
+	}
+	if q.Limit, err = queryInt32(ctx, "limit"); err != nil {
+		return nil, err
+	}
+	if q.Offset, err = queryInt32(ctx, "offset"); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// parseSongsQuery reads filters and paging options for the song listing.
+func parseSongsQuery(ctx fiber.Ctx) (*internal.SongsQuery, error) {
+	filter, err := parseGetSongsQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := &internal.SongsQuery{Filter: *filter}
+
+	if raw := ctx.Query("fuzzy"); raw != "" {
+		if q.Fuzzy, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("fuzzy must be a boolean")
+		}
+	}
+	if q.Sort, err = internal.ParseSort(ctx.Query("sort"), internal.SongSortFields); err != nil {
+		return nil, err
+	}
+	if q.Fields, q.Include, err = parseProjection(ctx, internal.DefaultListFields); err != nil {
+		return nil, err
+	}
+	if q.Cursor, err = parseCursor(ctx, q.Sort); err != nil {
+		return nil, err
+	}
+	if q.Cursor != nil && filter.Offset != nil {
+		return nil, fmt.Errorf("cursor and offset cannot be combined")
+	}
+	if raw := ctx.Query("withTotal"); raw != "" {
+		if q.WithTotal, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("withTotal must be a boolean")
+		}
+	}
+	return q, nil
+}
+
+// pageURL returns the request URL pointing at another page of the same listing.
+func pageURL(ctx fiber.Ctx, cursor string) string {
+	values, _ := url.ParseQuery(string(ctx.Request().URI().QueryString()))
+	values.Del("offset")
+	values.Set("cursor", cursor)
+	return ctx.BaseURL() + ctx.Path() + "?" + values.Encode()
+}
+
+// setPageLinks advertises the neighbouring pages in an RFC 8288 Link header.
+func setPageLinks(ctx fiber.Ctx, next, prev *string) {
+	var links []string
+	if next != nil {
+		links = append(links, pageURL(ctx, *next), "next")
+	}
+	if prev != nil {
+		links = append(links, pageURL(ctx, *prev), "prev")
+	}
+	ctx.Links(links...)
+}
+
+// parseCursor decodes the cursor parameter and checks it was issued for the given sort.
+func parseCursor(ctx fiber.Ctx, sort []internal.SortKey) (*internal.Cursor, error) {
+	token := ctx.Query("cursor")
+	if token == "" {
+		return nil, nil
+	}
+	return internal.DecodeCursorFor(token, sort)
+}
+
+// parseSearchQuery reads the search text, language and paging options.
+func parseSearchQuery(ctx fiber.Ctx) (*internal.SearchQuery, error) {
+	q := &internal.SearchQuery{Text: ctx.Query("q"), Language: ctx.Query("lang")}
+	if q.Text == "" {
+		return nil, fmt.Errorf("q is required")
+	}
+	if q.Language != "" && q.Language != "ru" && q.Language != "en" {
+		return nil, fmt.Errorf("lang must be ru or en")
+	}
+
+	var err error
+	if q.Limit, err = queryInt32(ctx, "limit"); err != nil {
+		return nil, err
+	}
+	if q.Sort, err = internal.ParseSort(ctx.Query("sort"), internal.SearchSortFields); err != nil {
+		return nil, err
+	}
+	if len(q.Sort) == 0 {
+		q.Sort = internal.DefaultSearchSort
+	}
+	if q.Fields, q.Include, err = parseProjection(ctx, internal.DefaultListFields); err != nil {
+		return nil, err
+	}
+	if q.Cursor, err = parseCursor(ctx, q.Sort); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"bytes"
+	"effectiveMobile/pkg/requestid"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// RequestID identifies every request by the X-Request-ID it was sent with, or a generated one
+// when it has none or one unfit for logs. The ID is carried in the user context of the request,
+// so the use case and repository log with it, and returned in X-Request-ID. JSON error bodies
+// get it as requestId.
+func RequestID() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		id := ctx.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		ctx.SetUserContext(requestid.NewContext(ctx.UserContext(), id))
+		ctx.Set(requestid.Header, id)
+
+		err := ctx.Next()
+		addRequestID(ctx, id)
+		return err
+	}
+}
+
+// addRequestID adds the request ID to a JSON object answering with an error status. Other bodies
+// are left as they are.
+func addRequestID(ctx fiber.Ctx, id string) {
+	resp := ctx.Response()
+	if resp.StatusCode() < fiber.StatusBadRequest || resp.IsBodyStream() ||
+		!bytes.HasPrefix(resp.Header.ContentType(), []byte(fiber.MIMEApplicationJSON)) {
+		return
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(resp.Body(), &body); err != nil || body == nil {
+		return
+	}
+	body["requestId"], _ = json.Marshal(id)
+	if raw, err := json.Marshal(body); err == nil {
+		resp.SetBody(raw)
+	}
+}
+
+// bind returns the handler serving the request of ctx, with the use case and logger bound to its
+// request ID.
+func (h Handler) bind(ctx fiber.Ctx) Handler {
+	h.useCase = h.useCase.WithContext(ctx.UserContext())
+	h.logger = h.logger.WithContext(ctx.UserContext())
+	return h
+}
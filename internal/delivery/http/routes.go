@@ -5,12 +5,56 @@ import (
 	"github.com/gofiber/fiber/v3"
 )
 
+// MapRoutes maps version 1 of the API.
 func MapRoutes(r fiber.Router, h handler.Handler) {
+	mapSongRoutes(r, h)
+	r.Post(`songs/text`, h.GetSongText())
+	mapAdminRoutes(r, h)
+
+	mapDocRoutes(r, "v1")
+}
+
+// MapRoutesV2 maps version 2 of the API, which drops the routes deprecated in version 1.
+func MapRoutesV2(r fiber.Router, h handler.Handler) {
+	mapSongRoutes(r, h)
+	mapAdminRoutes(r, h)
+
+	mapDocRoutes(r, "v2")
+}
+
+func mapSongRoutes(r fiber.Router, h handler.Handler) {
 	r.Get("/info", h.GetSongDetail())
 
 	r.Get(`songs`, h.GetSongs())
 	r.Get(`songs/text`, h.GetSongText())
-	r.Post(`songs`, h.CreateSong())
+	r.Get(`songs/:songId`, h.GetSong())
+	r.Get(`songs/:songId/text`, h.GetSongTextByID())
+	r.Post(`songs`, h.CreateSong(), h.Idempotent())
+	r.Post(`songs/batch`, h.CreateSongs(), h.Idempotent())
+	r.Patch(`songs/batch`, h.UpdateSongs(), h.Idempotent())
+	r.Delete(`songs/batch`, h.DeleteSongs(), h.Idempotent())
 	r.Patch(`songs/:songId`, h.UpdateSong())
 	r.Delete(`songs/:songId`, h.DeleteSong())
+
+	r.Get(`artists/:artistSlug/songs/:songSlug`, h.GetSongBySlug())
+
+	r.Get(`export`, h.ExportSongs())
+	r.Post(`imports`, h.ImportSongs())
+	r.Get(`imports/:importId`, h.GetImportReport())
+	r.Get(`imports/:importId/errors`, h.GetImportErrors())
+
+	r.Get(`search`, h.SearchSongs())
+	r.Get(`suggest`, h.Suggest())
+
+	r.Get(`events`, h.StreamEvents())
+	r.Get(`events/ws`, h.StreamEventsWebSocket())
+}
+
+func mapAdminRoutes(r fiber.Router, h handler.Handler) {
+	r.Post(`admin/webhooks`, h.CreateWebhook(), h.Admin())
+	r.Get(`admin/webhooks`, h.GetWebhooks(), h.Admin())
+	r.Delete(`admin/webhooks/:webhookId`, h.DeleteWebhook(), h.Admin())
+	r.Get(`admin/webhooks/:webhookId/deliveries`, h.GetWebhookDeliveries(), h.Admin())
+	r.Post(`admin/webhooks/:webhookId/deliveries/:deliveryId/redeliver`, h.RedeliverWebhook(), h.Admin())
+	r.Get(`admin/outbox`, h.GetOutboxStats(), h.Admin())
 }
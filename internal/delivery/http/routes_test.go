@@ -0,0 +1,42 @@
+package http
+
+import (
+	"effectiveMobile/config"
+	"effectiveMobile/pkg/logger"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func newTestApp() *fiber.App {
+	l := logger.NewApiLogger(&config.Config{})
+	_ = l.InitLogger()
+	cfg := &config.Config{}
+	app := fiber.New()
+	MapRoutes(app.Group("/v1"), NewHandler(nil, cfg, l, nil))
+	MapRoutesV2(app.Group("/v2"), NewHandlerV2(nil, cfg, l, nil))
+	return app
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	app := newTestApp()
+	for _, version := range []string{"v1", "v2"} {
+		t.Run(version, func(t *testing.T) {
+			if err := CheckRoutes(app.GetRoutes(), "/"+version, version); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCheckRoutesReportsDrift(t *testing.T) {
+	app := newTestApp()
+	app.Get("/v1/undocumented", func(fiber.Ctx) error { return nil })
+	if err := CheckRoutes(app.GetRoutes(), "/v1", "v1"); err == nil {
+		t.Fatal("CheckRoutes accepted a route missing from the spec")
+	}
+	// v2 routes are compared with the v2 spec, which drops the deprecated POST /songs/text.
+	if err := CheckRoutes(app.GetRoutes(), "/v2", "v1"); err == nil {
+		t.Fatal("CheckRoutes accepted v2 routes against the v1 spec")
+	}
+}
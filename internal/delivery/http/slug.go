@@ -0,0 +1,33 @@
+package http
+
+import (
+	"effectiveMobile/internal"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// slugParams returns the decoded artist and title slugs of the request path.
+func slugParams(ctx fiber.Ctx) (string, string, error) {
+	artistSlug, err := url.PathUnescape(ctx.Params("artistSlug"))
+	if err != nil {
+		return "", "", err
+	}
+	songSlug, err := url.PathUnescape(ctx.Params("songSlug"))
+	if err != nil {
+		return "", "", err
+	}
+	return artistSlug, songSlug, nil
+}
+
+// slugLocation returns the address of the song under the API version of the request, keeping the
+// query string.
+func slugLocation(ctx fiber.Ctx, slug internal.SongSlug) string {
+	path := ctx.Path()
+	location := path[:strings.Index(path, "/artists/")] + slug.Path()
+	if query := ctx.Request().URI().QueryString(); len(query) > 0 {
+		location += "?" + string(query)
+	}
+	return location
+}
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"effectiveMobile/config"
+	"effectiveMobile/internal"
+	"effectiveMobile/pkg/encoder"
+	"effectiveMobile/pkg/logger"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// HandlerV2 serves version 2 of the API. It only differs from version 1 where requests changed
+// incompatibly: the deprecated JSON body forms are gone. Every other endpoint is served by the
+// version 1 handlers.
+type HandlerV2 struct {
+	Handler
+}
+
+func NewHandlerV2(useCase internal.UseCase, cfg *config.Config, logger *logger.ApiLogger, encoders *encoder.Registry) *HandlerV2 {
+	return &HandlerV2{Handler: *NewHandler(useCase, cfg, logger, encoders)}
+}
+
+// GetSongs reads filters and paging options from query parameters only.
+func (h HandlerV2) GetSongs() fiber.Handler {
+	return h.listSongs
+}
+
+// GetSongText reads the song and the page of verses from query parameters only.
+func (h HandlerV2) GetSongText() fiber.Handler {
+	return h.songText
+}
@@ -0,0 +1,227 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"effectiveMobile/pkg/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func init() {
+	// Imports are validated as opaque files: the importer reports bad rows itself instead of
+	// rejecting the whole file.
+	openapi3filter.RegisterBodyDecoder("text/csv", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.FileBodyDecoder)
+	// Rendered lyrics are checked as strings.
+	for _, contentType := range []string{"text/markdown", "text/html", "text/x-lrc"} {
+		openapi3filter.RegisterBodyDecoder(contentType, openapi3filter.FileBodyDecoder)
+	}
+	// MessagePack bodies are checked against the same schemas as JSON.
+	for _, contentType := range []string{"application/msgpack", "application/x-msgpack"} {
+		openapi3filter.RegisterBodyDecoder(contentType, msgpackBodyDecoder)
+	}
+}
+
+// msgpackBodyDecoder decodes a MessagePack body into the values JSON would decode to, which the
+// schemas are checked against.
+func msgpackBodyDecoder(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+	var value any
+	if err := msgpack.NewDecoder(body).Decode(&value); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &value)
+	return value, err
+}
+
+// Validator checks requests, and in strict mode responses, against the OpenAPI document of
+// their API version.
+type Validator struct {
+	routers []routers.Router
+	strict  bool
+	logger  *logger.ApiLogger
+	options *openapi3filter.Options
+}
+
+// NewValidator builds a validator for every API version. Version 1 is also matched on the
+// unversioned aliases.
+func NewValidator(strict bool, logger *logger.ApiLogger) (*Validator, error) {
+	v := &Validator{
+		strict: strict,
+		logger: logger,
+		options: &openapi3filter.Options{
+			MultiError:            true,
+			IncludeResponseStatus: true,
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		},
+	}
+	for version, servers := range map[string][]string{"v1": {"/v1", "/"}, "v2": {"/v2"}} {
+		router, err := versionRouter(version, servers)
+		if err != nil {
+			return nil, err
+		}
+		v.routers = append(v.routers, router)
+	}
+	return v, nil
+}
+
+// versionRouter matches requests to the operations of an API version served under the given prefixes.
+func versionRouter(version string, prefixes []string) (routers.Router, error) {
+	spec, err := openAPIDocument(version)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("loading OpenAPI spec %s: %v", version, err)
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec %s: %v", version, err)
+	}
+
+	// Paths with their own servers, like GraphQL, are not versioned and validate their own requests.
+	for path, item := range doc.Paths.Map() {
+		if len(item.Servers) > 0 {
+			doc.Paths.Delete(path)
+		}
+	}
+	doc.Servers = nil
+	for _, prefix := range prefixes {
+		doc.Servers = append(doc.Servers, &openapi3.Server{URL: prefix})
+	}
+	return gorillamux.NewRouter(doc)
+}
+
+// Handler rejects requests violating the spec with 400. Routes the spec does not describe, like
+// the documentation, are passed through. In strict mode, responses violating the spec are
+// logged with the route and a JSON pointer to every failing field, but still sent.
+func (v *Validator) Handler() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		req, err := adaptor.ConvertRequest(ctx, false)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		input := v.requestInput(req)
+		if input == nil {
+			return ctx.Next()
+		}
+
+		if err = openapi3filter.ValidateRequest(req.Context(), input); err != nil {
+			v.logger.WithContext(ctx.UserContext()).Debugf("Rejected %s %s: %v", ctx.Method(), ctx.Path(), err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": strings.Join(violations(err), "; ")})
+		}
+
+		if err = ctx.Next(); err != nil || !v.strict {
+			return err
+		}
+		v.validateResponse(ctx, input)
+		return nil
+	}
+}
+
+func (v *Validator) requestInput(req *http.Request) *openapi3filter.RequestValidationInput {
+	for _, router := range v.routers {
+		route, params, err := router.FindRoute(req)
+		if err == nil {
+			return &openapi3filter.RequestValidationInput{Request: req, PathParams: params, Route: route, Options: v.options}
+		}
+	}
+	return nil
+}
+
+// validateResponse checks the response of a route. Streamed responses, like the export, are
+// skipped as reading them would buffer the whole stream.
+func (v *Validator) validateResponse(ctx fiber.Ctx, input *openapi3filter.RequestValidationInput) {
+	resp := ctx.Response()
+	if resp.IsBodyStream() {
+		return
+	}
+
+	header := http.Header{}
+	resp.Header.VisitAll(func(key, value []byte) {
+		header.Add(string(key), string(value))
+	})
+	// XML cannot be checked against the schemas, only the status and headers of the response.
+	options := v.options
+	if strings.HasPrefix(header.Get(fiber.HeaderContentType), fiber.MIMEApplicationXML) {
+		options = &openapi3filter.Options{IncludeResponseStatus: true, ExcludeResponseBody: true}
+	}
+	err := openapi3filter.ValidateResponse(input.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 resp.StatusCode(),
+		Header:                 header,
+		Body:                   io.NopCloser(bytes.NewReader(resp.Body())),
+		Options:                options,
+	})
+	if err != nil {
+		route := input.Route.Method + " " + strings.TrimSuffix(input.Route.Server.URL, "/") + input.Route.Path
+		for _, violation := range violations(err) {
+			v.logger.WithContext(ctx.UserContext()).Warnf("Response %d of %s violates the spec: %s", resp.StatusCode(), route, violation)
+		}
+	}
+}
+
+// violations describes every failure in a validation error. Body and response failures start
+// with a JSON pointer to the failing value.
+func violations(err error) []string {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var out []string
+		for _, err := range e {
+			out = append(out, violations(err)...)
+		}
+		return out
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			reason := e.Reason
+			var schemaErr *openapi3.SchemaError
+			if errors.As(e.Err, &schemaErr) {
+				reason = schemaErr.Reason
+			} else if e.Err != nil {
+				reason = e.Err.Error()
+			}
+			return []string{fmt.Sprintf("%s parameter %q: %s", e.Parameter.In, e.Parameter.Name, reason)}
+		}
+		if e.Err != nil {
+			return violations(e.Err)
+		}
+		return []string{"body: " + e.Reason}
+	case *openapi3filter.ResponseError:
+		if e.Err != nil {
+			return violations(e.Err)
+		}
+		return []string{e.Reason}
+	case *openapi3.SchemaError:
+		return []string{jsonPointer(e.JSONPointer()) + ": " + e.Reason}
+	}
+	return []string{err.Error()}
+}
+
+// jsonPointer formats a path as an RFC 6901 JSON pointer.
+func jsonPointer(path []string) string {
+	var b strings.Builder
+	for _, token := range path {
+		b.WriteString("/")
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"effectiveMobile/api"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	headerDeprecation = "Deprecation"
+	headerSunset      = "Sunset"
+
+	// httpDate is the date format of HTTP headers.
+	httpDate = "Mon, 02 Jan 2006 15:04:05 GMT"
+)
+
+// deprecate marks the response to a deprecated route or request form and tells when it stops being served.
+func (h Handler) deprecate(ctx fiber.Ctx) {
+	ctx.Set(headerDeprecation, "true")
+	ctx.Set(headerSunset, h.sunset.UTC().Format(httpDate))
+}
+
+// openAPIDocument derives the OpenAPI document of an API version from the embedded spec, which
+// describes version 1. Later versions leave out the operations deprecated in version 1.
+func openAPIDocument(version string) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(api.OpenAPI, &doc); err != nil {
+		return nil, fmt.Errorf("decoding OpenAPI spec: %v", err)
+	}
+
+	doc["servers"] = []any{map[string]any{"url": "/" + version}}
+	if info, ok := doc["info"].(map[string]any); ok {
+		info["title"] = fmt.Sprintf("%v %s", info["title"], version)
+	}
+	if version != "v1" {
+		paths, _ := doc["paths"].(map[string]any)
+		for path, item := range paths {
+			operations, _ := item.(map[string]any)
+			for method, operation := range operations {
+				if operation, ok := operation.(map[string]any); ok && operation["deprecated"] == true {
+					delete(operations, method)
+				}
+			}
+			if len(operations) == 0 {
+				delete(paths, path)
+			}
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// serveOpenAPI serves the OpenAPI document of an API version.
+func serveOpenAPI(version string) fiber.Handler {
+	doc, err := openAPIDocument(version)
+	return func(ctx fiber.Ctx) error {
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return ctx.Status(fiber.StatusOK).Send(doc)
+	}
+}
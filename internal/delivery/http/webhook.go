@@ -0,0 +1,53 @@
+package http
+
+import (
+	"crypto/subtle"
+	"effectiveMobile/internal"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 100
+)
+
+type createWebhookBody struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// admin lets requests through only with the admin token as bearer token. Without a configured
+// token the admin API is disabled.
+func (h Handler) admin(ctx fiber.Ctx) error {
+	if h.adminToken == "" {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Admin API is disabled"})
+	}
+	token, ok := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+		ctx.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	return ctx.Next()
+}
+
+// parseDeliveryQuery reads the status filter and the number of deliveries to list.
+func parseDeliveryQuery(ctx fiber.Ctx) (internal.DeliveryStatus, int, error) {
+	status := internal.DeliveryStatus(ctx.Query("status"))
+	switch status {
+	case "", internal.DeliveryPending, internal.DeliveryDelivered, internal.DeliveryDead:
+	default:
+		return "", 0, fmt.Errorf("status must be %s, %s or %s", internal.DeliveryPending, internal.DeliveryDelivered, internal.DeliveryDead)
+	}
+
+	limit, err := queryInt32(ctx, "limit")
+	if err != nil {
+		return "", 0, err
+	}
+	if limit == nil {
+		return status, defaultDeliveryLimit, nil
+	}
+	return status, min(int(*limit), maxDeliveryLimit), nil
+}
@@ -0,0 +1,88 @@
+package grpcServer
+
+import (
+	"context"
+	"effectiveMobile/config"
+	"effectiveMobile/internal"
+	delivery "effectiveMobile/internal/delivery/grpc"
+	songsv1 "effectiveMobile/pkg/api/songs/v1"
+	"effectiveMobile/pkg/logger"
+	"effectiveMobile/pkg/requestid"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Server serves the SongService next to the HTTP server, sharing its use case.
+type Server struct {
+	grpc      *grpc.Server
+	cfg       *config.Config
+	apiLogger *logger.ApiLogger
+}
+
+func NewServer(cfg *config.Config, useCase internal.UseCase, apiLogger *logger.ApiLogger) *Server {
+	s := &Server{cfg: cfg, apiLogger: apiLogger}
+	s.grpc = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.logUnary),
+		grpc.ChainStreamInterceptor(s.logStream),
+	)
+	songsv1.RegisterSongServiceServer(s.grpc, delivery.NewHandler(useCase, apiLogger))
+	reflection.Register(s.grpc)
+	return s
+}
+
+func (s *Server) Run() error {
+	listener, err := net.Listen("tcp", s.cfg.GRPC.Address)
+	if err != nil {
+		return err
+	}
+
+	s.apiLogger.Infof("Start gRPC server on address: %s", s.cfg.GRPC.Address)
+	return s.grpc.Serve(listener)
+}
+
+// logUnary logs every call with its status code and duration, like the HTTP request log. The
+// call is identified by its x-request-id metadata, or a generated ID returned in the header.
+func (s *Server) logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx = withRequestID(ctx)
+	resp, err := handler(ctx, req)
+	s.apiLogger.WithContext(ctx).Infof("%s %s %s", status.Code(err), time.Since(start), info.FullMethod)
+	return resp, err
+}
+
+func (s *Server) logStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := withRequestID(stream.Context())
+	err := handler(srv, &requestStream{ServerStream: stream, ctx: ctx})
+	s.apiLogger.WithContext(ctx).Infof("%s %s %s", status.Code(err), time.Since(start), info.FullMethod)
+	return err
+}
+
+// withRequestID returns ctx carrying the request ID of the call, and sends it back in the header.
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+	if values := metadata.ValueFromIncomingContext(ctx, strings.ToLower(requestid.Header)); len(values) > 0 {
+		id = values[0]
+	}
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+	return requestid.NewContext(ctx, id)
+}
+
+// requestStream hands the context carrying the request ID to stream handlers.
+type requestStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestStream) Context() context.Context {
+	return s.ctx
+}
@@ -10,8 +10,30 @@ import (
 type Handler interface {
 	GetSongDetail() fiber.Handler
 	GetSongs() fiber.Handler
+	GetSong() fiber.Handler
+	GetSongBySlug() fiber.Handler
+	ExportSongs() fiber.Handler
 	GetSongText() fiber.Handler
+	GetSongTextByID() fiber.Handler
+	Idempotent() fiber.Handler
 	CreateSong() fiber.Handler
 	UpdateSong() fiber.Handler
 	DeleteSong() fiber.Handler
+	CreateSongs() fiber.Handler
+	UpdateSongs() fiber.Handler
+	DeleteSongs() fiber.Handler
+	ImportSongs() fiber.Handler
+	GetImportReport() fiber.Handler
+	GetImportErrors() fiber.Handler
+	SearchSongs() fiber.Handler
+	Suggest() fiber.Handler
+	StreamEvents() fiber.Handler
+	StreamEventsWebSocket() fiber.Handler
+	Admin() fiber.Handler
+	CreateWebhook() fiber.Handler
+	GetWebhooks() fiber.Handler
+	DeleteWebhook() fiber.Handler
+	GetWebhookDeliveries() fiber.Handler
+	RedeliverWebhook() fiber.Handler
+	GetOutboxStats() fiber.Handler
 }
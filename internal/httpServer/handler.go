@@ -1,14 +1,18 @@
 package httpServer
 
 import (
+	"effectiveMobile/internal/delivery/graphql"
 	"effectiveMobile/internal/delivery/http"
 	repository "effectiveMobile/internal/repository"
 	useCase "effectiveMobile/internal/usecase"
 	"effectiveMobile/pkg/logger"
+	"effectiveMobile/pkg/requestid"
 	storage "effectiveMobile/pkg/storage/postgres"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
+	"github.com/gofiber/fiber/v3/middleware/etag"
 	serverLogger "github.com/gofiber/fiber/v3/middleware/logger"
+	"strings"
 )
 
 func (s *Server) MapHandlers(app *fiber.App, logger *logger.ApiLogger) error {
@@ -17,18 +21,69 @@ func (s *Server) MapHandlers(app *fiber.App, logger *logger.ApiLogger) error {
 		return err
 	}
 
-	repo := repository.NewPostgresRepository(db, logger)
-	useCase := useCase.NewUseCase(repo, logger)
-	handler := http.NewHandler(useCase, logger)
+	repo := repository.NewPostgresRepository(db, s.cfg, logger)
+	useCase := useCase.NewUseCase(repo, s.cfg, logger)
+	if err = useCase.AssignMissingSlugs(); err != nil {
+		return err
+	}
+	if err = useCase.RebuildSuggestions(); err != nil {
+		return err
+	}
+	s.useCase = useCase
+	handler := http.NewHandler(useCase, s.cfg, logger, s.encoders)
+	handlerV2 := http.NewHandlerV2(useCase, s.cfg, logger, s.encoders)
+	graphqlHandler, err := graphql.NewHandler(useCase, s.cfg, logger)
+	if err != nil {
+		return err
+	}
+	validator, err := http.NewValidator(s.cfg.Validation.Strict, logger)
+	if err != nil {
+		return err
+	}
 
-	app.Use(serverLogger.New())
+	// The request ID comes first, so the access log and every other middleware see it.
+	app.Use(http.RequestID())
+	app.Use(serverLogger.New(serverLogger.Config{
+		Format: "[${time}] ${ip} ${status} - ${latency} ${method} ${path} request_id=${respHeader:" + requestid.Header + "} ${error}\n",
+	}))
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: []string{},
 		AllowHeaders: []string{},
+		// Lets browser clients read the ID to report it.
+		ExposeHeaders: []string{requestid.Header},
+	}))
+	// Only imports take whole catalog files; every other route keeps Fiber's default limit.
+	app.Use(http.BodyLimit(func(ctx fiber.Ctx) int {
+		if ctx.Method() == fiber.MethodPost && strings.HasSuffix(ctx.Path(), "/imports") {
+			return s.cfg.Import.MaxBytes
+		}
+		return fiber.DefaultBodyLimit
 	}))
+	// Reads get a body-based ETag and answer If-None-Match with 304; single songs and writes use song versions instead.
+	// The export and the event feed are skipped because hashing their body would buffer the whole stream.
+	app.Use(etag.New(etag.Config{
+		Next: func(ctx fiber.Ctx) bool {
+			return ctx.Method() != fiber.MethodGet && ctx.Method() != fiber.MethodHead ||
+				strings.HasSuffix(ctx.Path(), "/export") || strings.HasSuffix(ctx.Path(), "/events")
+		},
+	}))
+	// Validation runs inside the ETag middleware, so strict mode sees full response bodies rather than 304s.
+	app.Use(validator.Handler())
+
+	http.MapRoutes(app.Group("/v1"), handler)
+	http.MapRoutesV2(app.Group("/v2"), handlerV2)
 
+	// Unversioned routes are kept as aliases of v1 for existing clients.
 	group := app.Group("")
 	http.MapRoutes(group, handler)
+	graphql.MapRoutes(group, graphqlHandler, s.cfg.Server.Dev)
+
+	// The routes are tested against the spec; a drift here is only reported.
+	for _, version := range []string{"v1", "v2"} {
+		if err = http.CheckRoutes(app.GetRoutes(), "/"+version, version); err != nil {
+			logger.Warnf("%v", err)
+		}
+	}
 
 	return nil
 }
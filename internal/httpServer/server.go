@@ -2,9 +2,14 @@ package httpServer
 
 import (
 	"effectiveMobile/config"
+	"effectiveMobile/internal"
+	"effectiveMobile/internal/grpcServer"
+	"effectiveMobile/pkg/encoder"
 	"effectiveMobile/pkg/logger"
+	"effectiveMobile/pkg/publisher"
 	gojson "github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v3"
+	"time"
 )
 
 // Server struct
@@ -12,6 +17,8 @@ type Server struct {
 	fiber     *fiber.App
 	cfg       *config.Config
 	apiLogger *logger.ApiLogger
+	useCase   internal.UseCase
+	encoders  *encoder.Registry
 }
 
 func NewServer(cfg *config.Config, apiLogger *logger.ApiLogger) *Server {
@@ -19,7 +26,19 @@ func NewServer(cfg *config.Config, apiLogger *logger.ApiLogger) *Server {
 		fiber: fiber.New(fiber.Config{
 			JSONEncoder: gojson.Marshal,
 			JSONDecoder: gojson.Unmarshal,
+			// Bodies are streamed to the BodyLimit middleware, which applies the limit of each
+			// route, so imports can take whole catalog files while other routes keep the default.
+			StreamRequestBody:            true,
+			DisablePreParseMultipartForm: true,
 		}),
+		// Song responses are encoded in the media type negotiated from Accept, JSON by default.
+		// CSV only represents listings.
+		encoders: encoder.NewRegistry().
+			Register(fiber.MIMEApplicationJSON, encoder.MarshalFunc(gojson.Marshal)).
+			Register(fiber.MIMEApplicationXML, encoder.XML).
+			Register("text/csv", encoder.CSV{}).
+			Register("application/msgpack", encoder.MessagePack{}).
+			Register("application/x-msgpack", encoder.MessagePack{}),
 		cfg:       cfg,
 		apiLogger: apiLogger,
 	}
@@ -30,6 +49,28 @@ func (s *Server) Run() error {
 		s.apiLogger.Fatalf("Cannot map handlers: %v", err)
 	}
 
+	// The gRPC API is optional and shares the use case, so both see the same suggestion index.
+	if s.cfg.GRPC.Address != "" {
+		rpc := grpcServer.NewServer(s.cfg, s.useCase, s.apiLogger)
+		go func() {
+			if err := rpc.Run(); err != nil {
+				s.apiLogger.Fatalf("Error starting gRPC server: %v", err)
+			}
+		}()
+	}
+
+	pub, err := publisher.New(s.cfg)
+	if err != nil {
+		s.apiLogger.Fatalf("Cannot create outbox publisher: %v", err)
+	}
+	defer pub.Close()
+
+	go s.purgeIdempotencyKeys()
+	go s.purgeSongEvents()
+	go s.useCase.RunEventFeed()
+	go s.useCase.RunWebhooks()
+	go s.useCase.RunOutboxRelay(pub)
+
 	s.apiLogger.Infof("Start server on address: %s", s.cfg.Server.Address)
 
 	if err := s.fiber.Listen(s.cfg.Server.Address); err != nil {
@@ -37,3 +78,21 @@ func (s *Server) Run() error {
 	}
 	return nil
 }
+
+// purgeIdempotencyKeys deletes expired idempotency keys every hour, so the table only holds responses that can still be replayed.
+func (s *Server) purgeIdempotencyKeys() {
+	for range time.Tick(time.Hour) {
+		if err := s.useCase.PurgeIdempotencyKeys(); err != nil {
+			s.apiLogger.Errorf("Failed to purge idempotency keys: %v", err)
+		}
+	}
+}
+
+// purgeSongEvents trims the change feed every hour to the events clients can still resume from.
+func (s *Server) purgeSongEvents() {
+	for range time.Tick(time.Hour) {
+		if err := s.useCase.PurgeSongEvents(); err != nil {
+			s.apiLogger.Errorf("Failed to purge song events: %v", err)
+		}
+	}
+}
@@ -1 +1,771 @@
 package internal
+
+import (
+	"effectiveMobile/pkg/suggest"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+	"github.com/google/uuid"
+)
+
+// SongSortFields lists the fields song listings can be ordered by.
+var SongSortFields = []string{"id", "group", "song", "releaseDate"}
+
+// SongFields lists the song fields clients can select.
+var SongFields = []string{"id", "group", "song", "releaseDate", "text", "link"}
+
+// DefaultListFields leaves lyrics out of listings.
+var DefaultListFields = []string{"id", "group", "song", "releaseDate", "link"}
+
+// SongIncludes lists the related resources that can be expanded into songs.
+var SongIncludes = []string{"artist", "links"}
+
+// SongsQuery describes a song listing: filters plus paging and ordering options.
+// Fuzzy extends the group and song filters to trigram matches, so misspelled names still match.
+// Fields selects the returned fields, all of them when empty; Include expands related resources.
+// Unpaged applies the filter's limit and offset as given, without page size bounds or cursors,
+// as the deprecated body form always did.
+type SongsQuery struct {
+	Filter    openapi.GetSongsBody
+	Fuzzy     bool
+	Sort      []SortKey
+	Cursor    *Cursor
+	WithTotal bool
+	Fields    []string
+	Include   []string
+	Unpaged   bool
+}
+
+var (
+	ErrSongNotFound    = errors.New("song not found")
+	ErrVersionMismatch = errors.New("song version mismatch")
+)
+
+// VersionedSong is a song with its revision number, which backs ETags and optimistic locking.
+type VersionedSong struct {
+	Song    *openapi.Song
+	Version int64
+	Slug    SongSlug
+}
+
+// SongResource is a whole song as written in responses to changes, named in XML like the songs of
+// listings.
+type SongResource struct {
+	XMLName     xml.Name `json:"-" xml:"song"`
+	Id          string   `json:"id" xml:"id"`
+	Group       string   `json:"group" xml:"group"`
+	Song        string   `json:"song" xml:"song"`
+	ReleaseDate string   `json:"releaseDate" xml:"releaseDate"`
+	Text        string   `json:"text" xml:"text"`
+	Link        string   `json:"link" xml:"link"`
+}
+
+func NewSongResource(song *openapi.Song) *SongResource {
+	return &SongResource{Id: song.Id, Group: song.Group, Song: song.Song, ReleaseDate: song.ReleaseDate, Text: song.Text, Link: song.Link}
+}
+
+// SongDetailResource is the detail of a song as served by the music info lookup.
+type SongDetailResource struct {
+	XMLName     xml.Name `json:"-" xml:"songDetail"`
+	ReleaseDate string   `json:"releaseDate" xml:"releaseDate"`
+	Text        string   `json:"text" xml:"text"`
+	Link        string   `json:"link" xml:"link"`
+}
+
+func NewSongDetailResource(detail *openapi.SongDetail) *SongDetailResource {
+	return &SongDetailResource{ReleaseDate: detail.ReleaseDate, Text: detail.Text, Link: detail.Link}
+}
+
+// SongView is a song limited to the selected fields, with optional related resources.
+type SongView struct {
+	XMLName     xml.Name   `json:"-" xml:"song"`
+	Id          string     `json:"id" xml:"id"`
+	Version     int64      `json:"version" xml:"version"`
+	Group       *string    `json:"group,omitempty" xml:"group,omitempty"`
+	Song        *string    `json:"song,omitempty" xml:"song,omitempty"`
+	ReleaseDate *string    `json:"releaseDate,omitempty" xml:"releaseDate,omitempty"`
+	Text        *string    `json:"text,omitempty" xml:"text,omitempty"`
+	Link        *string    `json:"link,omitempty" xml:"link,omitempty"`
+	Artist      *Artist    `json:"artist,omitempty" xml:"artist,omitempty"`
+	Links       *SongLinks `json:"links,omitempty" xml:"links,omitempty"`
+	Slug        SongSlug   `json:"-" xml:"-"`
+}
+
+// NewSongView keeps the selected fields of song; an empty selection keeps all of them.
+func NewSongView(versioned *VersionedSong, fields []string) *SongView {
+	selected := func(field string) bool {
+		return len(fields) == 0 || slices.Contains(fields, field)
+	}
+
+	song := versioned.Song
+	view := &SongView{Id: song.Id, Version: versioned.Version, Slug: versioned.Slug}
+	if selected("group") {
+		view.Group = &song.Group
+	}
+	if selected("song") {
+		view.Song = &song.Song
+	}
+	if selected("releaseDate") {
+		view.ReleaseDate = &song.ReleaseDate
+	}
+	if selected("text") {
+		view.Text = &song.Text
+	}
+	if selected("link") {
+		view.Link = &song.Link
+	}
+	return view
+}
+
+// Artist is the performer of songs, identified by the group name.
+type Artist struct {
+	Name      string `json:"name" xml:"name" db:"name"`
+	SongCount int64  `json:"songCount" xml:"songCount" db:"song_count"`
+}
+
+// SongLinks points at the resources related to a song.
+type SongLinks struct {
+	Self  string `json:"self" xml:"self"`
+	Slug  string `json:"slug,omitempty" xml:"slug,omitempty"`
+	Text  string `json:"text" xml:"text"`
+	Video string `json:"video,omitempty" xml:"video,omitempty"`
+}
+
+// SortKey is a single ORDER BY term of a listing.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+var ErrInvalidSort = errors.New("invalid sort")
+
+// ParseSort parses a comma-separated list such as "-releaseDate,group".
+// A leading "-" sorts the field in descending order; fields outside allowed are rejected.
+func ParseSort(raw string, allowed []string) ([]SortKey, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var keys []SortKey
+	seen := make(map[string]bool)
+	for _, term := range strings.Split(raw, ",") {
+		key := SortKey{Field: strings.TrimSpace(term)}
+		if strings.HasPrefix(key.Field, "-") {
+			key.Field, key.Desc = key.Field[1:], true
+		} else {
+			key.Field = strings.TrimPrefix(key.Field, "+")
+		}
+		if !slices.Contains(allowed, key.Field) || seen[key.Field] {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSort, term)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// FormatSort is the inverse of ParseSort.
+func FormatSort(keys []SortKey) string {
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		terms[i] = key.Field
+		if key.Desc {
+			terms[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+// SongsPage is a single page of a song listing.
+type SongsPage struct {
+	XMLName    xml.Name    `json:"-" xml:"songs"`
+	Items      []*SongView `json:"items" xml:"items>song"`
+	NextCursor *string     `json:"nextCursor,omitempty" xml:"nextCursor,omitempty"`
+	PrevCursor *string     `json:"prevCursor,omitempty" xml:"prevCursor,omitempty"`
+	Total      *int64      `json:"total,omitempty" xml:"total,omitempty"`
+	DidYouMean *DidYouMean `json:"didYouMean,omitempty" xml:"didYouMean,omitempty"`
+}
+
+// DidYouMean lists known artist and song names close to a query that matched nothing.
+type DidYouMean struct {
+	Group []string `json:"group,omitempty" xml:"group,omitempty"`
+	Song  []string `json:"song,omitempty" xml:"song,omitempty"`
+}
+
+// SearchSortFields lists the fields search results can be ordered by.
+var SearchSortFields = append(slices.Clone(SongSortFields), "relevance")
+
+// DefaultSearchSort orders search results by relevance when no sort is given.
+var DefaultSearchSort = []SortKey{{Field: "relevance", Desc: true}}
+
+// SearchQuery describes a full-text search over titles, artists and lyrics.
+// Language selects the text search configuration: "ru" or "en".
+type SearchQuery struct {
+	Text     string
+	Language string
+	Limit    *int32
+	Sort     []SortKey
+	Cursor   *Cursor
+	Fields   []string
+	Include  []string
+}
+
+// SearchHit is a song matching a search together with the matched fragments.
+type SearchHit struct {
+	XMLName   xml.Name        `json:"-" xml:"hit"`
+	Song      *SongView       `json:"song" xml:"song"`
+	Rank      float32         `json:"rank" xml:"rank"`
+	Highlight SearchHighlight `json:"highlight" xml:"highlight"`
+}
+
+// SearchHighlight marks matched terms with <mark> tags. Verse is the index of the first
+// matching verse counted from 0, its offset in GetSongText, and Snippet its excerpt.
+type SearchHighlight struct {
+	Group   string  `json:"group" xml:"group"`
+	Song    string  `json:"song" xml:"song"`
+	Verse   *int    `json:"verse,omitempty" xml:"verse,omitempty"`
+	Snippet *string `json:"snippet,omitempty" xml:"snippet,omitempty"`
+}
+
+// SearchPage is a single page of search results.
+type SearchPage struct {
+	XMLName    xml.Name     `json:"-" xml:"search"`
+	Items      []*SearchHit `json:"items" xml:"items>hit"`
+	NextCursor *string      `json:"nextCursor,omitempty" xml:"nextCursor,omitempty"`
+	PrevCursor *string      `json:"prevCursor,omitempty" xml:"prevCursor,omitempty"`
+	DidYouMean *DidYouMean  `json:"didYouMean,omitempty" xml:"didYouMean,omitempty"`
+}
+
+// SongName identifies a song by its artist and title.
+type SongName struct {
+	ID    string `db:"id"`
+	Group string `db:"group"`
+	Song  string `db:"song"`
+}
+
+// SongSlug is the human-readable address of a song, made of the slugs of its artist and title.
+// It is empty for songs that have not been given one yet.
+type SongSlug struct {
+	Artist string
+	Song   string
+}
+
+// Path returns the route of the song below the API version, e.g. /artists/muse/songs/uprising.
+func (s SongSlug) Path() string {
+	if s.Song == "" {
+		return ""
+	}
+	return "/artists/" + url.PathEscape(s.Artist) + "/songs/" + url.PathEscape(s.Song)
+}
+
+// Slugify turns a name into a slug: lowercase letters and digits, Cyrillic transliterated to
+// Latin, words joined by hyphens. Names without any letter or digit become "untitled".
+func Slugify(name string) string {
+	if slug := strings.ReplaceAll(suggest.Normalize(name), " ", "-"); slug != "" {
+		return slug
+	}
+	return "untitled"
+}
+
+// SongKey identifies a song detail by artist and title.
+type SongKey struct {
+	Group string
+	Song  string
+}
+
+var verseSeparator = regexp.MustCompile("\\\\n\\\\n")
+
+// SplitVerses splits lyrics into verses of lines. Verses are separated by an escaped blank line.
+func SplitVerses(text string) [][]string {
+	var verses [][]string
+	for _, verse := range verseSeparator.Split(text, -1) {
+		trimmedVerse := strings.TrimSpace(verse)
+		if trimmedVerse != "" {
+			lines := strings.Split(trimmedVerse, "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimSuffix(line, "\\n")
+			}
+			verses = append(verses, lines)
+		}
+	}
+	return verses
+}
+
+// chorusLabel matches the heading lines that mark a verse as a chorus, e.g. "[Chorus]" or "Припев:".
+var chorusLabel = regexp.MustCompile(`(?i)^\W*(chorus|refrain|hook|припев)\b`)
+
+// Verse is a verse of lyrics. Number counts the verses of the song and FirstLine its lines, both
+// from 1. A chorus either starts with a label such as "[Chorus]" or is repeated in the song.
+type Verse struct {
+	Number    int
+	FirstLine int
+	Lines     []string
+	Chorus    bool
+}
+
+// NumberVerses splits lyrics like SplitVerses and numbers the verses and their lines.
+func NumberVerses(text string) []Verse {
+	split := SplitVerses(text)
+	verses := make([]Verse, len(split))
+	repeats := make(map[string]int, len(split))
+	line := 1
+	for i, lines := range split {
+		verses[i] = Verse{Number: i + 1, FirstLine: line, Lines: lines, Chorus: chorusLabel.MatchString(lines[0])}
+		line += len(lines)
+		repeats[suggest.Normalize(strings.Join(lines, " "))]++
+	}
+	for i := range verses {
+		if repeats[suggest.Normalize(strings.Join(verses[i].Lines, " "))] > 1 {
+			verses[i].Chorus = true
+		}
+	}
+	return verses
+}
+
+// NumberRange is an inclusive range of numbers counted from 1. To is 0 for a range open to the end.
+type NumberRange struct {
+	From int
+	To   int
+}
+
+func (r NumberRange) Contains(n int) bool {
+	return n >= r.From && (r.To == 0 || n <= r.To)
+}
+
+// ParseRanges parses comma-separated numbers and ranges, e.g. "2-4", "1,3" or "5-" for 5 to the end.
+func ParseRanges(raw string) ([]NumberRange, error) {
+	var ranges []NumberRange
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		from, to, isRange := strings.Cut(item, "-")
+		r := NumberRange{}
+		var err error
+		if r.From, err = strconv.Atoi(from); err != nil || r.From < 1 {
+			return nil, fmt.Errorf("invalid range %q, expected N, N-M or N- with N from 1", item)
+		}
+		switch {
+		case !isRange:
+			r.To = r.From
+		case to != "":
+			if r.To, err = strconv.Atoi(to); err != nil || r.To < r.From {
+				return nil, fmt.Errorf("invalid range %q, expected N, N-M or N- with N from 1", item)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// inRanges reports whether n is in any of the ranges.
+func inRanges(ranges []NumberRange, n int) bool {
+	for _, r := range ranges {
+		if r.Contains(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// TextQuery selects lyrics of the song with SongID or, without it, of the song named by Group and Song.
+// Verses, Lines and Chorus narrow the verses down, Lines cutting them to the selected lines;
+// Offset and Limit then page through what is left.
+type TextQuery struct {
+	SongID string
+	Group  string
+	Song   string
+	Verses []NumberRange
+	Lines  []NumberRange
+	Chorus bool
+	Offset *int32
+	Limit  *int32
+}
+
+// Selects reports whether the query picks parts of the lyrics rather than paging through all verses.
+func (q *TextQuery) Selects() bool {
+	return len(q.Verses) > 0 || len(q.Lines) > 0 || q.Chorus
+}
+
+// Select returns the parts of the verses picked by Verses, Lines and Chorus. A verse cut by Lines
+// becomes one entry per run of consecutive selected lines.
+func (q *TextQuery) Select(verses []Verse) []Verse {
+	var selected []Verse
+	for _, verse := range verses {
+		if len(q.Verses) > 0 && !inRanges(q.Verses, verse.Number) || q.Chorus && !verse.Chorus {
+			continue
+		}
+		if len(q.Lines) == 0 {
+			selected = append(selected, verse)
+			continue
+		}
+		var run *Verse
+		for i, line := range verse.Lines {
+			number := verse.FirstLine + i
+			if !inRanges(q.Lines, number) {
+				run = nil
+				continue
+			}
+			if run == nil {
+				selected = append(selected, Verse{Number: verse.Number, FirstLine: number, Chorus: verse.Chorus})
+				run = &selected[len(selected)-1]
+			}
+			run.Lines = append(run.Lines, line)
+		}
+	}
+	return selected
+}
+
+// SongText is a page of lyrics. Verses holds the lines of each returned verse, Numbers the number
+// of each of them and FirstLines the number of its first line. Choruses lists the numbers of all
+// choruses of the song, so clients can build pagers together with the totals.
+type SongText struct {
+	Verses      [][]string `json:"verses"`
+	Numbers     []int      `json:"numbers"`
+	FirstLines  []int      `json:"firstLines"`
+	Choruses    []int      `json:"choruses"`
+	TotalVerses int        `json:"totalVerses"`
+	TotalLines  int        `json:"totalLines"`
+}
+
+// Suggestion is an autocomplete entry: an artist, or a song together with its artist.
+type Suggestion struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Group string `json:"group,omitempty"`
+	ID    string `json:"id,omitempty"`
+}
+
+const (
+	SuggestionArtist = "artist"
+	SuggestionSong   = "song"
+)
+
+// Cursor points at the boundary row of a page. Backward cursors select the rows before it.
+// Values holds the row's sort key values, in the order given by Sort.
+type Cursor struct {
+	Sort     string `json:"s,omitempty"`
+	Values   []any  `json:"v,omitempty"`
+	ID       string `json:"id"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode returns the opaque token handed out to clients.
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode.
+func DecodeCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err = json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// DecodeCursorFor parses a token like DecodeCursor and checks it was issued for the given sort,
+// since its values only make sense in the order they were taken from.
+func DecodeCursorFor(token string, sort []SortKey) (*Cursor, error) {
+	c, err := DecodeCursor(token)
+	if err != nil {
+		return nil, err
+	}
+	if c.Sort != FormatSort(sort) {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidCursor)
+	}
+	return c, nil
+}
+
+// BatchMode selects whether a batch is applied as a whole or item by item.
+type BatchMode string
+
+const (
+	BatchTransaction BatchMode = "transaction"
+	BatchBestEffort  BatchMode = "bestEffort"
+)
+
+var (
+	ErrInvalidBatch      = errors.New("invalid batch")
+	ErrInvalidItem       = errors.New("invalid batch item")
+	ErrRolledBack        = errors.New("rolled back because another item of the batch failed")
+	ErrDetailUnavailable = errors.New("song detail unavailable")
+)
+
+// SongUpdate is one item of an update batch.
+type SongUpdate struct {
+	ID        string                 `json:"id"`
+	IfVersion *int64                 `json:"ifVersion,omitempty"`
+	Changes   openapi.UpdateSongBody `json:"changes"`
+}
+
+// SongDelete is one item of a delete batch.
+type SongDelete struct {
+	ID        string `json:"id"`
+	IfVersion *int64 `json:"ifVersion,omitempty"`
+}
+
+// BatchResult is the outcome of one batch item, in request order. Err is nil on success.
+type BatchResult struct {
+	Index   int           `json:"index"`
+	Status  int           `json:"status"`
+	Id      string        `json:"id,omitempty"`
+	Version int64         `json:"version,omitempty"`
+	Song    *openapi.Song `json:"song,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Err     error         `json:"-"`
+}
+
+type BatchResponse struct {
+	Mode      BatchMode     `json:"mode"`
+	Committed bool          `json:"committed"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// DuplicatePolicy decides what an import does with rows naming a song that already exists.
+type DuplicatePolicy string
+
+const (
+	DuplicateSkip   DuplicatePolicy = "skip"
+	DuplicateUpsert DuplicatePolicy = "upsert"
+)
+
+// ImportFormats lists the accepted import file formats.
+var ImportFormats = []string{"csv", "ndjson"}
+
+// ImportFields lists the song fields an import can set; group and song are required.
+var ImportFields = []string{"group", "song", "releaseDate", "text", "link"}
+
+var (
+	ErrInvalidImport  = errors.New("invalid import")
+	ErrImportNotFound = errors.New("import not found")
+)
+
+type ImportOptions struct {
+	Format string
+	// Mapping names the source column or key of each song field; unmapped fields use their own name.
+	Mapping     map[string]string
+	DryRun      bool
+	OnDuplicate DuplicatePolicy
+	Enrich      bool
+}
+
+// ImportRow is a validated row ready to be written. Row is its position in the source file.
+type ImportRow struct {
+	Row  int
+	Song openapi.Song
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+type ImportCounts struct {
+	Inserted int64 `json:"inserted"`
+	Updated  int64 `json:"updated"`
+	Skipped  int64 `json:"skipped"`
+}
+
+// ImportReport summarizes an import. For dry runs the counts tell what the import would have done.
+type ImportReport struct {
+	ID          string          `json:"id"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	DryRun      bool            `json:"dryRun"`
+	Format      string          `json:"format"`
+	OnDuplicate DuplicatePolicy `json:"onDuplicate"`
+	Rows        int             `json:"rows"`
+	ImportCounts
+	Failed int              `json:"failed"`
+	Errors []ImportRowError `json:"errors"`
+}
+
+// ParseColumnMapping parses "field:column" pairs separated by commas, e.g. "group:Artist,song:Title".
+func ParseColumnMapping(raw string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if raw == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		field, column, ok := strings.Cut(pair, ":")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("mapping %q must be written as field:column", pair)
+		}
+		if !slices.Contains(ImportFields, field) {
+			return nil, fmt.Errorf("mapping: unknown field %q, expected one of %s", field, strings.Join(ImportFields, ", "))
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+// WriteErrorsCSV writes the row errors as CSV with a header row.
+func (r *ImportReport) WriteErrorsCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"row", "field", "error"}); err != nil {
+		return err
+	}
+	for _, e := range r.Errors {
+		if err := out.Write([]string{strconv.Itoa(e.Row), e.Field, e.Error}); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+var (
+	ErrIdempotencyKeyInUse    = errors.New("a request with this Idempotency-Key is still being processed")
+	ErrIdempotencyKeyMismatch = errors.New("this Idempotency-Key was already used for a different request")
+)
+
+// IdempotentResponse is a response stored under an Idempotency-Key, replayed on retries.
+type IdempotentResponse struct {
+	Status int
+	Header map[string]string
+	Body   []byte
+}
+
+// IdempotencyRecord is the state of an Idempotency-Key. Response is nil while the first request
+// is still being processed.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Response    *IdempotentResponse
+}
+
+// Song events, saved in the transaction of the change they describe.
+const (
+	EventSongCreated = "song.created"
+	EventSongUpdated = "song.updated"
+	EventSongDeleted = "song.deleted"
+)
+
+// SongEvents lists the events webhooks can subscribe to.
+var SongEvents = []string{EventSongCreated, EventSongUpdated, EventSongDeleted}
+
+// SongEvent describes a change to a song. Song is left out for deletions, but Group is kept so
+// deletions can be filtered by artist too. Seq is the position of the event in the change feed.
+type SongEvent struct {
+	Seq        int64         `json:"seq,omitempty"`
+	ID         string        `json:"id"`
+	Type       string        `json:"type"`
+	OccurredAt time.Time     `json:"occurredAt"`
+	SongID     string        `json:"songId"`
+	Group      string        `json:"group,omitempty"`
+	Version    int64         `json:"version,omitempty"`
+	Song       *openapi.Song `json:"song,omitempty"`
+}
+
+// NewSongEvent describes a change to a song, to be saved in the transaction making it.
+func NewSongEvent(eventType, songID, group string, version int64, song *openapi.Song) *SongEvent {
+	return &SongEvent{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		SongID:     songID,
+		Group:      group,
+		Version:    version,
+		Song:       song,
+	}
+}
+
+// EventFilter selects the events of a change feed subscription by artist or song. An empty list
+// matches every event.
+type EventFilter struct {
+	Groups  []string
+	SongIDs []string
+}
+
+// Match reports whether the event passes the filter. Artists are matched case-insensitively.
+func (f EventFilter) Match(event *SongEvent) bool {
+	if len(f.SongIDs) > 0 && !slices.Contains(f.SongIDs, event.SongID) {
+		return false
+	}
+	if len(f.Groups) > 0 && !slices.ContainsFunc(f.Groups, func(group string) bool {
+		return strings.EqualFold(group, event.Group)
+	}) {
+		return false
+	}
+	return true
+}
+
+var (
+	ErrInvalidWebhook   = errors.New("invalid webhook")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// Webhook is a subscription of a URL to song events. Secret signs the payloads; it is only
+// returned when the webhook is created.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// DeliveryStatus tells whether a webhook delivery is still being attempted.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead marks a dead letter: a delivery given up after too many failed attempts.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// WebhookDelivery is one event sent to one webhook, with the outcome of its last attempt.
+type WebhookDelivery struct {
+	ID            string          `json:"id"`
+	WebhookID     string          `json:"webhookId"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        DeliveryStatus  `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	LastStatus    *int            `json:"lastStatus,omitempty"`
+	LastError     *string         `json:"lastError,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	DeliveredAt   *time.Time      `json:"deliveredAt,omitempty"`
+
+	// URL and Secret are those of the webhook, filled in when the delivery is claimed for sending.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// OutboxStats tells how far the relay lags behind the writes. Pending and OldestPending cover
+// every replica; the other figures are those of the relay running on this replica, if any.
+type OutboxStats struct {
+	Pending       int64      `json:"pending"`
+	OldestPending *time.Time `json:"oldestPending,omitempty"`
+	// LagSeconds is the age of the oldest pending event, 0 when the relay is caught up.
+	LagSeconds      float64    `json:"lagSeconds"`
+	Relaying        bool       `json:"relaying"`
+	Published       int64      `json:"published"`
+	Failed          int64      `json:"failed"`
+	LastPublishedAt *time.Time `json:"lastPublishedAt,omitempty"`
+	// LastLatencySeconds is the time the last published event spent in the outbox.
+	LastLatencySeconds float64 `json:"lastLatencySeconds"`
+}
@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []SortKey
+		wantErr bool
+	}{
+		{raw: "", want: nil},
+		{raw: "group", want: []SortKey{{Field: "group"}}},
+		{raw: "+group", want: []SortKey{{Field: "group"}}},
+		{raw: "-releaseDate", want: []SortKey{{Field: "releaseDate", Desc: true}}},
+		{raw: "-releaseDate, group,id", want: []SortKey{{Field: "releaseDate", Desc: true}, {Field: "group"}, {Field: "id"}}},
+		{raw: "text", wantErr: true},
+		{raw: "group,group", wantErr: true},
+		{raw: "group,-group", wantErr: true},
+		{raw: "group,", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseSort(tt.raw, SongSortFields)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSort) {
+					t.Fatalf("ParseSort(%q) error = %v, want ErrInvalidSort", tt.raw, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSort(%q) error = %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseSort(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSortRoundTrip(t *testing.T) {
+	for _, raw := range []string{"", "id", "-releaseDate,group", "song,-group,-id"} {
+		keys, err := ParseSort(raw, SongSortFields)
+		if err != nil {
+			t.Fatalf("ParseSort(%q) error = %v", raw, err)
+		}
+		if got := FormatSort(keys); got != raw {
+			t.Errorf("FormatSort(ParseSort(%q)) = %q", raw, got)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{ID: "6b7b1a52-0f5c-4bb2-9e3c-6b0b6e4a7c11"},
+		{Sort: "-releaseDate,group", Values: []any{"20060716", "Muse"}, ID: "u1"},
+		{Sort: "group", Values: []any{""}, ID: "u2", Backward: true},
+		{Sort: "-relevance", Values: []any{0.25}, ID: "u3"},
+	}
+	for _, c := range tests {
+		got, err := DecodeCursor(c.Encode())
+		if err != nil {
+			t.Fatalf("DecodeCursor(%+v) error = %v", c, err)
+		}
+		if !reflect.DeepEqual(*got, c) {
+			t.Errorf("DecodeCursor(Encode(%+v)) = %+v", c, *got)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for name, token := range map[string]string{
+		"empty":      "",
+		"not base64": "***",
+		"not json":   "bm90IGpzb24",
+		"no id":      Cursor{Sort: "group", Values: []any{"Muse"}}.Encode(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecodeCursor(token); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", token, err)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorFor(t *testing.T) {
+	token := Cursor{Sort: "-releaseDate,group", Values: []any{"20060716", "Muse"}, ID: "u1"}.Encode()
+	tests := []struct {
+		sort    string
+		wantErr bool
+	}{
+		{sort: "-releaseDate,group"},
+		{sort: "releaseDate,group", wantErr: true},
+		{sort: "group,-releaseDate", wantErr: true},
+		{sort: "-releaseDate", wantErr: true},
+		{sort: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			keys, err := ParseSort(tt.sort, SongSortFields)
+			if err != nil {
+				t.Fatalf("ParseSort(%q) error = %v", tt.sort, err)
+			}
+			_, err = DecodeCursorFor(token, keys)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("DecodeCursorFor(sort %q) error = %v, want error %v", tt.sort, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("DecodeCursorFor(sort %q) error = %v, want ErrInvalidCursor", tt.sort, err)
+			}
+		})
+	}
+}
+
+func TestParseColumnMapping(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{raw: "", want: map[string]string{}},
+		{raw: "group:Artist", want: map[string]string{"group": "Artist"}},
+		{raw: " group : Artist , song:Title", want: map[string]string{"group": "Artist", "song": "Title"}},
+		{raw: "link:URL:2", want: map[string]string{"link": "URL:2"}},
+		{raw: "group", wantErr: true},
+		{raw: "group:", wantErr: true},
+		{raw: "artist:Group", wantErr: true},
+		{raw: "group:Artist,", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseColumnMapping(tt.raw)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ParseColumnMapping(%q) error = %v, want error %v", tt.raw, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseColumnMapping(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportReportWriteErrorsCSV(t *testing.T) {
+	report := &ImportReport{Errors: []ImportRowError{
+		{Row: 3, Field: "releaseDate", Error: "must be a date formatted as DD.MM.YYYY"},
+		{Row: 5, Error: `bare " in non-quoted-field`},
+	}}
+	var out strings.Builder
+	if err := report.WriteErrorsCSV(&out); err != nil {
+		t.Fatalf("WriteErrorsCSV error = %v", err)
+	}
+	want := "row,field,error\n" +
+		"3,releaseDate,must be a date formatted as DD.MM.YYYY\n" +
+		"5,,\"bare \"\" in non-quoted-field\"\n"
+	if out.String() != want {
+		t.Fatalf("WriteErrorsCSV =\n%s\nwant\n%s", out.String(), want)
+	}
+}
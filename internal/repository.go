@@ -3,15 +3,57 @@
 package internal
 
 import (
+	"context"
+	"time"
+
 	openapi "github.com/Lineblaze/effective_mobile_gen"
 )
 
 // Controller describes methods, implemented by the repository package.
 type Repository interface {
+	SaveSongEvent(event *SongEvent) error
+	GetSongEvent(eventID string) (*SongEvent, error)
+	GetSongEventsAfter(seq int64, limit int) ([]*SongEvent, error)
+	GetLastSongEventSeq() (int64, error)
+	ListenSongEvents(ctx context.Context, listening func(), fn func(eventID string)) error
+	DeleteSongEventsBefore(cutoff time.Time) (int64, error)
+	ExportSongs(q *SongsQuery, fn func(song *VersionedSong) error) error
+	ClaimIdempotencyKey(key, fingerprint string, ttl, lockTimeout time.Duration) (*IdempotencyRecord, error)
+	SaveIdempotentResponse(key string, resp *IdempotentResponse) error
+	ReleaseIdempotencyKey(key string) error
+	DeleteExpiredIdempotencyKeys() (int64, error)
+	ImportSongs(rows []ImportRow, policy DuplicatePolicy, commit bool) (*ImportCounts, error)
+	SaveImportReport(report *ImportReport) error
+	GetImportReport(importID string) (*ImportReport, error)
+	SaveOutboxEvent(event *SongEvent) error
+	LockOutbox() (bool, error)
+	GetOutboxEvents(limit int, skipSongs []string) ([]*SongEvent, error)
+	DeleteOutboxEvents(eventIDs []string) error
+	GetOutboxStats() (*OutboxStats, error)
+	InTx(fn func(repo Repository) error) error
+	WithContext(ctx context.Context) Repository
 	GetSongDetail(group, song string) (*openapi.SongDetail, error)
-	GetSongs(body *openapi.GetSongsBody) ([]*openapi.Song, error)
+	GetSongDetails(keys []SongKey) (map[SongKey]*openapi.SongDetail, error)
+	GetSongs(q *SongsQuery) ([]*VersionedSong, []Cursor, error)
+	CountSongs(q *SongsQuery) (int64, error)
+	SuggestNames(group, song *string, limit int) (*DidYouMean, error)
+	GetArtists(names []string) ([]*Artist, error)
+	GetSongNames() ([]SongName, error)
 	GetSongText(group, song string) (string, error)
-	CreateSong(song *openapi.Song) (*openapi.Song, error)
-	UpdateSong(songID string, req *openapi.UpdateSongBody) (*openapi.Song, error)
-	DeleteSong(songID string) error
+	GetSongTextByID(songID string) (string, error)
+	CreateSong(song *openapi.Song) (*VersionedSong, error)
+	UpdateSong(songID string, req *openapi.UpdateSongBody, ifVersion *int64) (*VersionedSong, error)
+	DeleteSong(songID string, ifVersion *int64) (string, error)
+	SearchSongs(q *SearchQuery) ([]*SearchHit, []Cursor, error)
+	GetSong(songID string, fields []string) (*VersionedSong, error)
+	GetSongBySlug(artistSlug, slug string, fields []string) (*VersionedSong, error)
+	AssignMissingSlugs() (int, error)
+	CreateWebhook(webhook *Webhook) error
+	GetWebhooks() ([]*Webhook, error)
+	DeleteWebhook(webhookID string) error
+	EnqueueWebhookDeliveries(event string, payload []byte) (int64, error)
+	ClaimWebhookDeliveries(limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	SaveWebhookAttempt(delivery *WebhookDelivery) error
+	GetWebhookDeliveries(webhookID string, status DeliveryStatus, limit int) ([]*WebhookDelivery, error)
+	RedeliverWebhookDelivery(webhookID, deliveryID string) (*WebhookDelivery, error)
 }
@@ -0,0 +1,113 @@
+package postgresql
+
+import (
+	"context"
+	"effectiveMobile/internal"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// songEventsChannel is the notification channel announcing new song events by ID.
+const songEventsChannel = "song_events"
+
+// SaveSongEvent appends the event to the change feed and notifies every listener of it once the
+// insert is committed.
+func (p *PostgresRepository) SaveSongEvent(event *internal.SongEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding song event: %v", err)
+	}
+	_, err = p.db.Exec(`
+		WITH saved AS (
+			INSERT INTO song_events (id, type, song_id, payload, occurred_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		)
+		SELECT pg_notify($6, id::text) FROM saved
+	`, event.ID, event.Type, event.SongID, payload, event.OccurredAt, songEventsChannel)
+	if err != nil {
+		p.logger.Errorf("failed to save song event: %v", err)
+		return fmt.Errorf("saving song event: %v", err)
+	}
+	return nil
+}
+
+// GetSongEvent returns the event with the given ID along with its position in the feed.
+func (p *PostgresRepository) GetSongEvent(eventID string) (*internal.SongEvent, error) {
+	var (
+		seq     int64
+		payload []byte
+	)
+	err := p.db.QueryRow(`SELECT seq, payload FROM song_events WHERE id::text = $1`, eventID).Scan(&seq, &payload)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("song event %s not found", eventID)
+	}
+	if err != nil {
+		p.logger.Errorf("failed to get song event: %v", err)
+		return nil, fmt.Errorf("getting song event: %v", err)
+	}
+	return decodeSongEvent(seq, payload)
+}
+
+// GetSongEventsAfter returns up to limit events following seq in the feed, oldest first.
+func (p *PostgresRepository) GetSongEventsAfter(seq int64, limit int) ([]*internal.SongEvent, error) {
+	rows, err := p.db.Query(`SELECT seq, payload FROM song_events WHERE seq > $1 ORDER BY seq LIMIT $2`, seq, limit)
+	if err != nil {
+		p.logger.Errorf("failed to get song events: %v", err)
+		return nil, fmt.Errorf("getting song events: %v", err)
+	}
+	defer rows.Close()
+
+	var events []*internal.SongEvent
+	for rows.Next() {
+		var payload []byte
+		if err = rows.Scan(&seq, &payload); err != nil {
+			return nil, fmt.Errorf("scanning song event: %v", err)
+		}
+		event, err := decodeSongEvent(seq, payload)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// GetLastSongEventSeq returns the position of the latest event in the feed, 0 if it is empty.
+func (p *PostgresRepository) GetLastSongEventSeq() (int64, error) {
+	var seq int64
+	if err := p.db.QueryRow(`SELECT COALESCE(max(seq), 0) FROM song_events`).Scan(&seq); err != nil {
+		p.logger.Errorf("failed to get last song event: %v", err)
+		return 0, fmt.Errorf("getting last song event: %v", err)
+	}
+	return seq, nil
+}
+
+// ListenSongEvents calls fn with the ID of every event saved by any replica once listening has
+// been called, until ctx is done or the connection is lost.
+func (p *PostgresRepository) ListenSongEvents(ctx context.Context, listening func(), fn func(eventID string)) error {
+	return p.db.Listen(ctx, songEventsChannel, listening, fn)
+}
+
+// DeleteSongEventsBefore trims the feed of the events older than cutoff and returns how many there were.
+func (p *PostgresRepository) DeleteSongEventsBefore(cutoff time.Time) (int64, error) {
+	tag, err := p.db.Exec(`DELETE FROM song_events WHERE occurred_at < $1`, cutoff)
+	if err != nil {
+		p.logger.Errorf("failed to delete song events: %v", err)
+		return 0, fmt.Errorf("deleting song events: %v", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func decodeSongEvent(seq int64, payload []byte) (*internal.SongEvent, error) {
+	event := &internal.SongEvent{}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("decoding song event: %v", err)
+	}
+	event.Seq = seq
+	return event, nil
+}
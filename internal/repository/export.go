@@ -0,0 +1,79 @@
+package postgresql
+
+import (
+	"effectiveMobile/internal"
+	"effectiveMobile/pkg/storage/postgres"
+	"fmt"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+)
+
+// exportFetchSize is the number of rows pulled from the export cursor at a time.
+const exportFetchSize = 500
+
+// ExportSongs calls fn for every song matching the filters, in sort order. Rows are read through
+// a server-side cursor, so memory use does not depend on the size of the catalog. The cursor lives
+// in a read-only transaction that stays open until fn has seen the last row or returned an error.
+func (p *PostgresRepository) ExportSongs(q *internal.SongsQuery, fn func(song *internal.VersionedSong) error) error {
+	p.logger.Debug("Exporting songs with filter parameters")
+
+	terms, err := sortTerms(q.Sort, songSortColumns)
+	if err != nil {
+		p.logger.Errorf("failed to build song order: %v", err)
+		return fmt.Errorf("building order: %v", err)
+	}
+	where, params := p.songsFilter(q)
+	columns, scanDest := selectSongFields(q.Fields)
+	query := `SELECT ` + columns + ` FROM songs` + where + orderBy(terms, false)
+
+	exported := 0
+	err = postgres.ExecTx(p.ctx, p.db, func(tx postgres.Tx) error {
+		conn := tx.Conn(p.ctx)
+		if _, err := conn.Exec(`SET TRANSACTION READ ONLY`); err != nil {
+			return fmt.Errorf("setting transaction mode: %v", err)
+		}
+		if q.Fuzzy {
+			if err := p.setSimilarityThreshold(conn); err != nil {
+				return err
+			}
+		}
+		if _, err := conn.Exec(`DECLARE songs_export NO SCROLL CURSOR FOR `+query, params...); err != nil {
+			return fmt.Errorf("declaring cursor: %v", err)
+		}
+
+		for {
+			rows, err := conn.Query(fmt.Sprintf(`FETCH FORWARD %d FROM songs_export`, exportFetchSize))
+			if err != nil {
+				return fmt.Errorf("fetching rows: %v", err)
+			}
+			fetched := 0
+			for rows.Next() {
+				song := &internal.VersionedSong{Song: &openapi.Song{}}
+				if err = rows.Scan(scanDest(song)...); err != nil {
+					rows.Close()
+					return fmt.Errorf("failed to scan row: %v", err)
+				}
+				if err = fn(song); err != nil {
+					rows.Close()
+					return err
+				}
+				fetched++
+			}
+			rows.Close()
+			if err = rows.Err(); err != nil {
+				return fmt.Errorf("row iteration error: %v", err)
+			}
+			exported += fetched
+			if fetched < exportFetchSize {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		p.logger.Errorf("failed to export songs after %d rows: %v", exported, err)
+		return fmt.Errorf("exporting songs: %w", err)
+	}
+
+	p.logger.Infof("Successfully exported %d songs", exported)
+	return nil
+}
@@ -0,0 +1,43 @@
+package postgresql
+
+import (
+	"effectiveMobile/internal"
+	"slices"
+	"strings"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+)
+
+// songFieldColumns maps selectable API fields to columns, in SELECT order.
+var songFieldColumns = []struct {
+	field  string
+	column string
+	dest   func(song *openapi.Song) any
+}{
+	{"group", `COALESCE("group", '')`, func(s *openapi.Song) any { return &s.Group }},
+	{"song", `COALESCE(song, '')`, func(s *openapi.Song) any { return &s.Song }},
+	{"releaseDate", `COALESCE(release_date, '')`, func(s *openapi.Song) any { return &s.ReleaseDate }},
+	{"text", `COALESCE("text", '')`, func(s *openapi.Song) any { return &s.Text }},
+	{"link", `COALESCE(link, '')`, func(s *openapi.Song) any { return &s.Link }},
+}
+
+// selectSongFields renders the select list for the requested fields; id, version and the slugs are
+// always selected. An empty list selects every field. The returned function yields the scan destinations.
+func selectSongFields(fields []string) (string, func(song *internal.VersionedSong) []any) {
+	columns := []string{"id", "version", "COALESCE(artist_slug, '')", "COALESCE(slug, '')"}
+	var dests []func(song *openapi.Song) any
+	for _, c := range songFieldColumns {
+		if len(fields) == 0 || slices.Contains(fields, c.field) {
+			columns = append(columns, c.column)
+			dests = append(dests, c.dest)
+		}
+	}
+
+	return strings.Join(columns, ", "), func(song *internal.VersionedSong) []any {
+		dest := []any{&song.Song.Id, &song.Version, &song.Slug.Artist, &song.Slug.Song}
+		for _, d := range dests {
+			dest = append(dest, d(song.Song))
+		}
+		return dest
+	}
+}
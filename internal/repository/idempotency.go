@@ -0,0 +1,101 @@
+package postgresql
+
+import (
+	"effectiveMobile/internal"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ClaimIdempotencyKey reserves key for a request with the given fingerprint until ttl has passed.
+// It returns nil when the key was claimed: it is new, expired, or its request was abandoned for
+// longer than lockTimeout without storing a response. Otherwise the stored record is returned.
+// The claim is a single statement, so concurrent requests with the same key cannot both win it.
+func (p *PostgresRepository) ClaimIdempotencyKey(key, fingerprint string, ttl, lockTimeout time.Duration) (*internal.IdempotencyRecord, error) {
+	p.logger.Debugf("Claiming idempotency key: %s", key)
+	var claimed string
+	err := p.db.QueryRow(`
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+		VALUES ($1, $2, now() + make_interval(secs => $3))
+		ON CONFLICT (key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+		    locked_at = now(),
+		    expires_at = EXCLUDED.expires_at,
+		    status = NULL,
+		    header = NULL,
+		    body = NULL
+		WHERE idempotency_keys.expires_at < now()
+		   OR (idempotency_keys.status IS NULL AND idempotency_keys.locked_at < now() - make_interval(secs => $4))
+		RETURNING key
+	`, key, fingerprint, ttl.Seconds(), lockTimeout.Seconds()).Scan(&claimed)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		p.logger.Errorf("failed to claim idempotency key: %v", err)
+		return nil, fmt.Errorf("claiming idempotency key: %v", err)
+	}
+
+	var (
+		status *int
+		header []byte
+		record = &internal.IdempotencyRecord{}
+		resp   = &internal.IdempotentResponse{}
+	)
+	err = p.db.QueryRow(`SELECT fingerprint, status, header, body FROM idempotency_keys WHERE key = $1`, key).
+		Scan(&record.Fingerprint, &status, &header, &resp.Body)
+	if err != nil {
+		p.logger.Errorf("failed to get idempotency key: %v", err)
+		return nil, fmt.Errorf("getting idempotency key: %v", err)
+	}
+	if status == nil {
+		return record, nil
+	}
+	resp.Status = *status
+	if err = json.Unmarshal(header, &resp.Header); err != nil {
+		return nil, fmt.Errorf("decoding stored response header: %v", err)
+	}
+	record.Response = resp
+	return record, nil
+}
+
+// SaveIdempotentResponse stores the response of the request holding key.
+func (p *PostgresRepository) SaveIdempotentResponse(key string, resp *internal.IdempotentResponse) error {
+	p.logger.Debugf("Saving response for idempotency key: %s", key)
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return fmt.Errorf("encoding response header: %v", err)
+	}
+
+	_, err = p.db.Exec(`UPDATE idempotency_keys SET status = $2, header = $3, body = $4 WHERE key = $1`,
+		key, resp.Status, header, resp.Body)
+	if err != nil {
+		p.logger.Errorf("failed to save idempotent response: %v", err)
+		return fmt.Errorf("saving idempotent response: %v", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey gives up a claim without a response, so the request can be retried.
+func (p *PostgresRepository) ReleaseIdempotencyKey(key string) error {
+	p.logger.Debugf("Releasing idempotency key: %s", key)
+	_, err := p.db.Exec(`DELETE FROM idempotency_keys WHERE key = $1 AND status IS NULL`, key)
+	if err != nil {
+		p.logger.Errorf("failed to release idempotency key: %v", err)
+		return fmt.Errorf("releasing idempotency key: %v", err)
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes the keys whose TTL has passed and returns how many there were.
+func (p *PostgresRepository) DeleteExpiredIdempotencyKeys() (int64, error) {
+	tag, err := p.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at < now()`)
+	if err != nil {
+		p.logger.Errorf("failed to delete expired idempotency keys: %v", err)
+		return 0, fmt.Errorf("deleting expired idempotency keys: %v", err)
+	}
+	return tag.RowsAffected(), nil
+}
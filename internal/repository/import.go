@@ -0,0 +1,176 @@
+package postgresql
+
+import (
+	"effectiveMobile/internal"
+	"effectiveMobile/pkg/storage/postgres"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+	"github.com/jackc/pgx/v5"
+)
+
+// errDryRun rolls back the import transaction once the counts are known.
+var errDryRun = errors.New("dry run")
+
+var importColumns = []string{"row_no", "group", "song", "release_date", "text", "link"}
+
+// ImportSongs writes the rows in one transaction. They are loaded into a temporary table with COPY,
+// then merged into songs by artist and title: new songs are inserted, existing ones are updated
+// or left alone depending on policy. Without commit the transaction is rolled back after the
+// merge, so a dry run reports exactly what the import would do. New songs get their slugs, and
+// every song written gets its event in the outbox, in the same transaction.
+func (p *PostgresRepository) ImportSongs(rows []internal.ImportRow, policy internal.DuplicatePolicy, commit bool) (*internal.ImportCounts, error) {
+	p.logger.Debugf("Importing %d songs", len(rows))
+
+	counts := &internal.ImportCounts{}
+	err := postgres.ExecTx(p.ctx, p.db, func(tx postgres.Tx) error {
+		conn := tx.Conn(p.ctx)
+		_, err := conn.Exec(`
+			CREATE TEMP TABLE songs_import (
+				row_no INT, "group" TEXT, song TEXT, release_date VARCHAR(50), "text" TEXT, link TEXT
+			) ON COMMIT DROP
+		`)
+		if err != nil {
+			return fmt.Errorf("creating staging table: %v", err)
+		}
+
+		_, err = conn.CopyFrom(pgx.Identifier{"songs_import"}, importColumns, pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+			song := rows[i].Song
+			return []any{rows[i].Row, song.Group, song.Song, nullIfEmpty(song.ReleaseDate), nullIfEmpty(song.Text), nullIfEmpty(song.Link)}, nil
+		}))
+		if err != nil {
+			return fmt.Errorf("copying rows: %v", err)
+		}
+
+		var events []*internal.SongEvent
+		if policy == internal.DuplicateUpsert {
+			// Empty cells keep the stored value.
+			updated, err := importedSongs(conn, internal.EventSongUpdated, `
+				UPDATE songs s
+				SET release_date = COALESCE(i.release_date, s.release_date),
+				    "text" = COALESCE(i."text", s."text"),
+				    link = COALESCE(i.link, s.link),
+				    version = s.version + 1
+				FROM songs_import i
+				WHERE s."group" = i."group" AND s.song = i.song
+				RETURNING `+importedColumns)
+			if err != nil {
+				return fmt.Errorf("updating existing songs: %v", err)
+			}
+			counts.Updated, events = int64(len(updated)), updated
+		} else {
+			err = conn.QueryRow(`
+				SELECT count(*) FROM songs_import i
+				WHERE EXISTS (SELECT 1 FROM songs s WHERE s."group" = i."group" AND s.song = i.song)
+			`).Scan(&counts.Skipped)
+			if err != nil {
+				return fmt.Errorf("counting existing songs: %v", err)
+			}
+		}
+
+		inserted, err := importedSongs(conn, internal.EventSongCreated, `
+			INSERT INTO songs ("group", song, release_date, "text", link)
+			SELECT i."group", i.song, i.release_date, i."text", i.link
+			FROM songs_import i
+			WHERE NOT EXISTS (SELECT 1 FROM songs s WHERE s."group" = i."group" AND s.song = i.song)
+			ORDER BY i.row_no
+			RETURNING `+importedColumns)
+		if err != nil {
+			return fmt.Errorf("inserting new songs: %v", err)
+		}
+		counts.Inserted, events = int64(len(inserted)), append(events, inserted...)
+
+		if !commit {
+			return errDryRun
+		}
+		for _, event := range inserted {
+			if _, err = assignSlug(conn, event.SongID, event.Song.Group, event.Song.Song); err != nil {
+				return err
+			}
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return saveOutboxEvents(conn, events)
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		p.logger.Errorf("failed to import songs: %v", err)
+		return nil, fmt.Errorf("importing songs: %v", err)
+	}
+
+	p.logger.Infof("Successfully imported songs: %d inserted, %d updated, %d skipped", counts.Inserted, counts.Updated, counts.Skipped)
+	return counts, nil
+}
+
+// SaveImportReport stores the report and fills in its ID and creation time.
+func (p *PostgresRepository) SaveImportReport(report *internal.ImportReport) error {
+	p.logger.Debug("Saving import report")
+	raw, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("encoding import report: %v", err)
+	}
+
+	err = p.db.QueryRow(`INSERT INTO song_imports (report) VALUES ($1) RETURNING id, created_at`, raw).
+		Scan(&report.ID, &report.CreatedAt)
+	if err != nil {
+		p.logger.Errorf("failed to save import report: %v", err)
+		return fmt.Errorf("saving import report: %v", err)
+	}
+	return nil
+}
+
+func (p *PostgresRepository) GetImportReport(importID string) (*internal.ImportReport, error) {
+	p.logger.Debugf("Getting import report with ID: %s", importID)
+	var raw []byte
+	report := &internal.ImportReport{}
+	err := p.db.QueryRow(`SELECT id, created_at, report FROM song_imports WHERE id::text = $1`, importID).
+		Scan(&report.ID, &report.CreatedAt, &raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, internal.ErrImportNotFound
+	}
+	if err != nil {
+		p.logger.Errorf("failed to get import report: %v", err)
+		return nil, fmt.Errorf("getting import report: %v", err)
+	}
+
+	id, createdAt := report.ID, report.CreatedAt
+	if err = json.Unmarshal(raw, report); err != nil {
+		return nil, fmt.Errorf("decoding import report: %v", err)
+	}
+	report.ID, report.CreatedAt = id, createdAt
+	return report, nil
+}
+
+// importedColumns are the columns of the written songs read back by importedSongs.
+const importedColumns = `id, COALESCE("group", ''), COALESCE(song, ''), COALESCE(release_date, ''), COALESCE("text", ''), COALESCE(link, ''), version`
+
+// importedSongs runs a statement writing songs and describes every written song with an event.
+func importedSongs(db postgres.Postgres, eventType, query string) ([]*internal.SongEvent, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*internal.SongEvent
+	for rows.Next() {
+		var (
+			song    openapi.Song
+			version int64
+		)
+		if err = rows.Scan(&song.Id, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &version); err != nil {
+			return nil, err
+		}
+		events = append(events, internal.NewSongEvent(eventType, song.Id, song.Group, version, &song))
+	}
+	return events, rows.Err()
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,109 @@
+package postgresql
+
+import (
+	"effectiveMobile/internal"
+	"effectiveMobile/pkg/storage/postgres"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// outboxLock is the advisory lock held by the relay, so a single replica publishes at a time and
+// events of a song cannot overtake each other.
+const outboxLock = 7_374_001
+
+// SaveOutboxEvent records the event. Call it on a repository from InTx so the event is committed
+// with the change it describes, or not at all.
+func (p *PostgresRepository) SaveOutboxEvent(event *internal.SongEvent) error {
+	return saveOutboxEvents(p.db, []*internal.SongEvent{event})
+}
+
+// LockOutbox takes the relay lock until the end of the transaction and reports whether it was
+// free. Call it on a repository from InTx.
+func (p *PostgresRepository) LockOutbox() (bool, error) {
+	var locked bool
+	if err := p.db.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, outboxLock).Scan(&locked); err != nil {
+		p.logger.Errorf("failed to lock outbox: %v", err)
+		return false, fmt.Errorf("locking outbox: %v", err)
+	}
+	return locked, nil
+}
+
+// GetOutboxEvents returns up to limit pending events in the order they were saved, leaving out
+// every event of skipSongs. Writes to a song lock its row until they commit, and save their event
+// after the write, so the events of a song are always saved in the order of its changes.
+func (p *PostgresRepository) GetOutboxEvents(limit int, skipSongs []string) ([]*internal.SongEvent, error) {
+	if skipSongs == nil {
+		// A NULL array would match no event at all.
+		skipSongs = []string{}
+	}
+	rows, err := p.db.Query(`SELECT payload FROM outbox WHERE song_id::text <> ALL($2) ORDER BY seq LIMIT $1`, limit, skipSongs)
+	if err != nil {
+		p.logger.Errorf("failed to get outbox events: %v", err)
+		return nil, fmt.Errorf("getting outbox events: %v", err)
+	}
+	defer rows.Close()
+
+	var events []*internal.SongEvent
+	for rows.Next() {
+		var payload []byte
+		if err = rows.Scan(&payload); err != nil {
+			return nil, fmt.Errorf("scanning outbox event: %v", err)
+		}
+		event := &internal.SongEvent{}
+		if err = json.Unmarshal(payload, event); err != nil {
+			return nil, fmt.Errorf("decoding outbox event: %v", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// DeleteOutboxEvents removes the published events.
+func (p *PostgresRepository) DeleteOutboxEvents(eventIDs []string) error {
+	_, err := p.db.Exec(`DELETE FROM outbox WHERE id::text = ANY($1)`, eventIDs)
+	if err != nil {
+		p.logger.Errorf("failed to delete outbox events: %v", err)
+		return fmt.Errorf("deleting outbox events: %v", err)
+	}
+	return nil
+}
+
+// GetOutboxStats counts the pending events and finds the oldest one.
+func (p *PostgresRepository) GetOutboxStats() (*internal.OutboxStats, error) {
+	stats := &internal.OutboxStats{}
+	err := p.db.QueryRow(`SELECT count(*), min(occurred_at) FROM outbox`).Scan(&stats.Pending, &stats.OldestPending)
+	if err != nil {
+		p.logger.Errorf("failed to get outbox stats: %v", err)
+		return nil, fmt.Errorf("getting outbox stats: %v", err)
+	}
+	return stats, nil
+}
+
+// saveOutboxEvents inserts the events into the outbox in their order, with a single statement.
+func saveOutboxEvents(db postgres.Postgres, events []*internal.SongEvent) error {
+	var (
+		ids, types, songIDs, payloads []string
+		occurredAt                    []time.Time
+	)
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encoding %s event: %v", event.Type, err)
+		}
+		ids, types, songIDs = append(ids, event.ID), append(types, event.Type), append(songIDs, event.SongID)
+		payloads, occurredAt = append(payloads, string(payload)), append(occurredAt, event.OccurredAt)
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO outbox (id, type, song_id, payload, occurred_at)
+		SELECT id::uuid, type, song_id::uuid, payload::jsonb, occurred_at
+		FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::timestamptz[]) WITH ORDINALITY
+		     AS e (id, type, song_id, payload, occurred_at, n)
+		ORDER BY n
+	`, ids, types, songIDs, payloads, occurredAt)
+	if err != nil {
+		return fmt.Errorf("saving outbox events: %v", err)
+	}
+	return nil
+}
@@ -1,21 +1,44 @@
 package postgresql
 
 import (
+	"context"
+	"effectiveMobile/config"
+	"effectiveMobile/internal"
 	"effectiveMobile/pkg/logger"
 	"effectiveMobile/pkg/storage/postgres"
+	"errors"
 	"fmt"
 	openapi "github.com/Lineblaze/effective_mobile_gen"
+	"github.com/jackc/pgx/v5"
+	"slices"
+	"strconv"
 	"strings"
 )
 
-//go:generate ifacemaker -f postgres.go -o ../repository.go -i Repository -s PostgresRepository -p internal -y "Controller describes methods, implemented by the repository package."
+//go:generate ifacemaker -f *.go -o ../repository.go -i Repository -s PostgresRepository -p internal -y "Controller describes methods, implemented by the repository package."
 type PostgresRepository struct {
-	db     postgres.Postgres
-	logger *logger.ApiLogger
+	db                  postgres.Postgres
+	logger              *logger.ApiLogger
+	similarityThreshold float64
+	ctx                 context.Context
 }
 
-func NewPostgresRepository(db postgres.Postgres, logger *logger.ApiLogger) *PostgresRepository {
-	return &PostgresRepository{db: db, logger: logger}
+func NewPostgresRepository(db postgres.Postgres, cfg *config.Config, logger *logger.ApiLogger) *PostgresRepository {
+	return &PostgresRepository{db: db, logger: logger, similarityThreshold: cfg.Search.SimilarityThreshold, ctx: context.Background()}
+}
+
+// InTx runs fn with a repository bound to one transaction, which is committed if fn returns nil
+// and rolled back otherwise.
+func (p *PostgresRepository) InTx(fn func(repo internal.Repository) error) error {
+	return postgres.ExecTx(p.ctx, p.db, func(tx postgres.Tx) error {
+		return fn(&PostgresRepository{db: tx.Conn(p.ctx), logger: p.logger, similarityThreshold: p.similarityThreshold, ctx: p.ctx})
+	})
+}
+
+// WithContext returns the repository running its statements with ctx and logging them with the
+// request ID it carries.
+func (p *PostgresRepository) WithContext(ctx context.Context) internal.Repository {
+	return &PostgresRepository{db: p.db.WithContext(ctx), logger: p.logger.WithContext(ctx), similarityThreshold: p.similarityThreshold, ctx: ctx}
 }
 
 func (p *PostgresRepository) GetSongDetail(group, song string) (*openapi.SongDetail, error) {
@@ -35,81 +58,295 @@ func (p *PostgresRepository) GetSongDetail(group, song string) (*openapi.SongDet
 	return &songDetail, nil
 }
 
-func (p *PostgresRepository) GetSongs(body *openapi.GetSongsBody) ([]*openapi.Song, error) {
-	p.logger.Debug("Getting songs with filter parameters")
+// GetSongDetails fetches the details of several songs with a single query. Songs without details are left out.
+func (p *PostgresRepository) GetSongDetails(keys []internal.SongKey) (map[internal.SongKey]*openapi.SongDetail, error) {
+	p.logger.Debugf("Fetching %d song details", len(keys))
+	groups := make([]string, len(keys))
+	songs := make([]string, len(keys))
+	for i, key := range keys {
+		groups[i], songs[i] = key.Group, key.Song
+	}
+
+	rows, err := p.db.Query(`
+		SELECT d."group", d.song, COALESCE(d.release_date, ''), COALESCE(d.text, ''), COALESCE(d.link, '')
+		FROM songs_detail d
+		JOIN unnest($1::text[], $2::text[]) AS k("group", song) ON d."group" = k."group" AND d.song = k.song
+	`, groups, songs)
+	if err != nil {
+		p.logger.Errorf("failed to fetch song details: %v", err)
+		return nil, fmt.Errorf("fetching song details: %v", err)
+	}
+	defer rows.Close()
+
+	details := make(map[internal.SongKey]*openapi.SongDetail, len(keys))
+	for rows.Next() {
+		var key internal.SongKey
+		var detail openapi.SongDetail
+		if err = rows.Scan(&key.Group, &key.Song, &detail.ReleaseDate, &detail.Text, &detail.Link); err != nil {
+			return nil, fmt.Errorf("scanning song detail: %v", err)
+		}
+		details[key] = &detail
+	}
+	if err = rows.Err(); err != nil {
+		p.logger.Errorf("failed to fetch song details: %v", err)
+		return nil, fmt.Errorf("fetching song details: %v", err)
+	}
 
-	query := `SELECT id, "group", song, release_date, "text", link FROM songs WHERE 1=1`
-	var params []interface{}
-	paramIdx := 1
+	p.logger.Infof("Successfully fetched %d song details", len(details))
+	return details, nil
+}
+
+// songsFilter builds the WHERE clause shared by song listings and counts.
+func (p *PostgresRepository) songsFilter(q *internal.SongsQuery) (string, []any) {
+	body := &q.Filter
+	where := ` WHERE 1=1`
+	var params []any
 
 	if body.Id != nil {
-		query += fmt.Sprintf(" AND id = $%d", paramIdx)
 		params = append(params, *body.Id)
-		paramIdx++
+		where += fmt.Sprintf(" AND id = $%d", len(params))
 	}
 	if body.Group != nil {
-		query += fmt.Sprintf(" AND \"group\" ILIKE $%d", paramIdx)
-		params = append(params, "%"+*body.Group+"%")
-		paramIdx++
+		var cond string
+		cond, params = p.nameMatch(`"group"`, *body.Group, q.Fuzzy, params)
+		where += " AND " + cond
 	}
 	if body.Song != nil {
-		query += fmt.Sprintf(" AND song ILIKE $%d", paramIdx)
-		params = append(params, "%"+*body.Song+"%")
-		paramIdx++
+		var cond string
+		cond, params = p.nameMatch(`song`, *body.Song, q.Fuzzy, params)
+		where += " AND " + cond
 	}
 	if body.ReleaseDate != nil {
-		query += fmt.Sprintf(" AND release_date = $%d", paramIdx)
 		params = append(params, *body.ReleaseDate)
-		paramIdx++
+		where += fmt.Sprintf(" AND release_date = $%d", len(params))
 	}
 	if body.Text != nil {
-		query += fmt.Sprintf(" AND \"text\" ILIKE $%d", paramIdx)
 		params = append(params, "%"+*body.Text+"%")
-		paramIdx++
+		where += fmt.Sprintf(" AND \"text\" ILIKE $%d", len(params))
 	}
 	if body.Link != nil {
-		query += fmt.Sprintf(" AND link ILIKE $%d", paramIdx)
 		params = append(params, "%"+*body.Link+"%")
-		paramIdx++
-	}
-	if body.Limit != nil {
-		query += fmt.Sprintf(" LIMIT %d", *body.Limit)
+		where += fmt.Sprintf(" AND link ILIKE $%d", len(params))
 	}
-	if body.Offset != nil {
-		query += fmt.Sprintf(" OFFSET %d", *body.Offset)
+
+	return where, params
+}
+
+// nameMatch matches a substring of the column and, when fuzzy, names similar to the value.
+// The % operator lets the trigram index narrow rows down using pg_trgm.similarity_threshold,
+// so fuzzy queries run through similarityTx, which sets it to the configured threshold.
+func (p *PostgresRepository) nameMatch(column, value string, fuzzy bool, params []any) (string, []any) {
+	params = append(params, "%"+value+"%")
+	cond := fmt.Sprintf("%s ILIKE $%d", column, len(params))
+	if !fuzzy {
+		return cond, params
 	}
 
-	rows, err := p.db.Query(query, params...)
+	params = append(params, value, p.similarityThreshold)
+	cond += fmt.Sprintf(" OR (%[1]s %% $%[2]d AND similarity(%[1]s, $%[2]d) >= $%[3]d)", column, len(params)-1, len(params))
+	return "(" + cond + ")", params
+}
+
+// GetSongs returns the requested songs together with the keyset position of each row.
+func (p *PostgresRepository) GetSongs(q *internal.SongsQuery) ([]*internal.VersionedSong, []internal.Cursor, error) {
+	p.logger.Debug("Getting songs with filter parameters")
+
+	terms, err := sortTerms(q.Sort, songSortColumns)
 	if err != nil {
-		p.logger.Errorf("failed to get songs: %v", err)
-		return nil, fmt.Errorf("failed to execute query: %v", err)
+		p.logger.Errorf("failed to build song order: %v", err)
+		return nil, nil, fmt.Errorf("building order: %v", err)
 	}
-	defer rows.Close()
 
-	var songs []*openapi.Song
-	for rows.Next() {
-		var song openapi.Song
-		if err = rows.Scan(&song.Id, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link); err != nil {
-			p.logger.Errorf("failed to scan song: %v", err)
-			return nil, fmt.Errorf("failed to scan row: %v", err)
+	where, params := p.songsFilter(q)
+	backward := q.Cursor != nil && q.Cursor.Backward
+	if q.Cursor != nil {
+		var keyset string
+		if keyset, params, err = keysetCondition(terms, q.Cursor, params); err != nil {
+			p.logger.Errorf("failed to apply cursor: %v", err)
+			return nil, nil, fmt.Errorf("applying cursor: %v", err)
 		}
-		songs = append(songs, &song)
+		where += keyset
 	}
 
-	if err = rows.Err(); err != nil {
-		p.logger.Errorf("error reading rows: %v", err)
-		return nil, fmt.Errorf("row iteration error: %v", err)
+	columns, scanDest := selectSongFields(q.Fields)
+	query := `SELECT ` + columns + selectSortValues(terms) + ` FROM songs` + where + orderBy(terms, backward)
+	if q.Filter.Limit != nil {
+		query += fmt.Sprintf(" LIMIT %d", *q.Filter.Limit)
+	}
+	if q.Cursor == nil && q.Filter.Offset != nil {
+		query += fmt.Sprintf(" OFFSET %d", *q.Filter.Offset)
+	}
+
+	sortName := internal.FormatSort(q.Sort)
+	var songs []*internal.VersionedSong
+	var cursors []internal.Cursor
+	err = p.similarityTx(q.Fuzzy, func(db postgres.Postgres) error {
+		rows, err := db.Query(query, params...)
+		if err != nil {
+			p.logger.Errorf("failed to get songs: %v", err)
+			return fmt.Errorf("failed to execute query: %v", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			song := &internal.VersionedSong{Song: &openapi.Song{}}
+			values := make([]any, len(terms)-1)
+			dest := scanDest(song)
+			for i := range values {
+				dest = append(dest, &values[i])
+			}
+			if err = rows.Scan(dest...); err != nil {
+				p.logger.Errorf("failed to scan song: %v", err)
+				return fmt.Errorf("failed to scan row: %v", err)
+			}
+			songs = append(songs, song)
+			cursors = append(cursors, internal.Cursor{Sort: sortName, Values: values, ID: song.Song.Id})
+		}
+
+		if err = rows.Err(); err != nil {
+			p.logger.Errorf("error reading rows: %v", err)
+			return fmt.Errorf("row iteration error: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if backward {
+		slices.Reverse(songs)
+		slices.Reverse(cursors)
 	}
 
 	p.logger.Infof("Successfully retrieved %d songs", len(songs))
-	return songs, nil
+	return songs, cursors, nil
+}
+
+func (p *PostgresRepository) CountSongs(q *internal.SongsQuery) (int64, error) {
+	p.logger.Debug("Counting songs with filter parameters")
+
+	where, params := p.songsFilter(q)
+	var total int64
+	err := p.similarityTx(q.Fuzzy, func(db postgres.Postgres) error {
+		return db.QueryRow(`SELECT COUNT(*) FROM songs`+where, params...).Scan(&total)
+	})
+	if err != nil {
+		p.logger.Errorf("failed to count songs: %v", err)
+		return 0, fmt.Errorf("counting songs: %v", err)
+	}
+
+	p.logger.Infof("Successfully counted %d songs", total)
+	return total, nil
+}
+
+// SuggestNames returns the artist and song names most similar to the given values.
+func (p *PostgresRepository) SuggestNames(group, song *string, limit int) (*internal.DidYouMean, error) {
+	p.logger.Debug("Suggesting similar names")
+
+	var suggestions internal.DidYouMean
+	var err error
+	if group != nil {
+		if suggestions.Group, err = p.similarNames(`"group"`, *group, limit); err != nil {
+			return nil, err
+		}
+	}
+	if song != nil {
+		if suggestions.Song, err = p.similarNames(`song`, *song, limit); err != nil {
+			return nil, err
+		}
+	}
+
+	p.logger.Infof("Successfully suggested %d groups and %d songs", len(suggestions.Group), len(suggestions.Song))
+	return &suggestions, nil
+}
+
+func (p *PostgresRepository) similarNames(column, value string, limit int) ([]string, error) {
+	query := fmt.Sprintf(`
+		SELECT name FROM (
+			SELECT DISTINCT %[1]s AS name, similarity(%[1]s, $1) AS score
+			FROM songs
+			WHERE %[1]s %% $1 AND similarity(%[1]s, $1) >= $2
+		) s
+		ORDER BY score DESC, name
+		LIMIT $3
+	`, column)
+
+	var names []string
+	err := p.similarityTx(true, func(db postgres.Postgres) error {
+		return db.Select(&names, query, value, p.similarityThreshold, limit)
+	})
+	if err != nil {
+		p.logger.Errorf("failed to suggest names: %v", err)
+		return nil, fmt.Errorf("suggesting names: %v", err)
+	}
+	return names, nil
+}
+
+// similarityTx runs fn on the repository's connection. Fuzzy queries run in a transaction whose
+// pg_trgm.similarity_threshold is the configured one, since the % operator reads its threshold
+// from there and the server default would otherwise cut off lower configured thresholds.
+func (p *PostgresRepository) similarityTx(fuzzy bool, fn func(db postgres.Postgres) error) error {
+	if !fuzzy {
+		return fn(p.db)
+	}
+	return postgres.ExecTx(p.ctx, p.db, func(tx postgres.Tx) error {
+		conn := tx.Conn(p.ctx)
+		if err := p.setSimilarityThreshold(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// setSimilarityThreshold sets pg_trgm.similarity_threshold until the end of the transaction of conn.
+func (p *PostgresRepository) setSimilarityThreshold(conn postgres.Postgres) error {
+	threshold := strconv.FormatFloat(p.similarityThreshold, 'f', -1, 64)
+	if _, err := conn.Exec(`SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, threshold); err != nil {
+		return fmt.Errorf("setting similarity threshold: %v", err)
+	}
+	return nil
+}
+
+// GetArtists returns the named artists with their song counts.
+func (p *PostgresRepository) GetArtists(names []string) ([]*internal.Artist, error) {
+	p.logger.Debugf("Fetching %d artists", len(names))
+	var artists []*internal.Artist
+	err := p.db.Select(&artists, `
+		SELECT "group" AS name, COUNT(*) AS song_count
+		FROM songs
+		WHERE "group" = ANY($1)
+		GROUP BY "group"
+	`, names)
+	if err != nil {
+		p.logger.Errorf("failed to fetch artists: %v", err)
+		return nil, fmt.Errorf("fetching artists: %v", err)
+	}
+
+	p.logger.Infof("Successfully fetched %d artists", len(artists))
+	return artists, nil
+}
+
+func (p *PostgresRepository) GetSongNames() ([]internal.SongName, error) {
+	p.logger.Debug("Fetching all song names")
+	var names []internal.SongName
+	err := p.db.Select(&names, `SELECT id, COALESCE("group", '') AS "group", COALESCE(song, '') AS song FROM songs`)
+	if err != nil {
+		p.logger.Errorf("failed to fetch song names: %v", err)
+		return nil, fmt.Errorf("fetching song names: %v", err)
+	}
+
+	p.logger.Infof("Successfully fetched %d song names", len(names))
+	return names, nil
 }
 
 func (p *PostgresRepository) GetSongText(group, song string) (string, error) {
 	p.logger.Debugf("Fetching song text for group: %s, song: %s", group, song)
 	var songText string
-	query := `SELECT "text" FROM songs WHERE "group" = $1 AND song = $2`
+	query := `SELECT COALESCE("text", '') FROM songs WHERE "group" = $1 AND song = $2`
 	err := p.db.QueryRow(query, group, song).Scan(&songText)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", internal.ErrSongNotFound
+	}
 	if err != nil {
 		p.logger.Errorf("failed to fetch song text: %v", err)
 		return "", fmt.Errorf("failed to get song text: %v", err)
@@ -119,15 +356,32 @@ func (p *PostgresRepository) GetSongText(group, song string) (string, error) {
 	return songText, nil
 }
 
-func (p *PostgresRepository) CreateSong(song *openapi.Song) (*openapi.Song, error) {
+func (p *PostgresRepository) GetSongTextByID(songID string) (string, error) {
+	p.logger.Debugf("Fetching song text for song with ID: %s", songID)
+	var songText string
+	err := p.db.QueryRow(`SELECT COALESCE("text", '') FROM songs WHERE id = $1`, songID).Scan(&songText)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", internal.ErrSongNotFound
+	}
+	if err != nil {
+		p.logger.Errorf("failed to fetch song text: %v", err)
+		return "", fmt.Errorf("getting song text: %v", err)
+	}
+
+	p.logger.Infof("Successfully fetched song text for song with ID: %s", songID)
+	return songText, nil
+}
+
+func (p *PostgresRepository) CreateSong(song *openapi.Song) (*internal.VersionedSong, error) {
 	p.logger.Debugf("Creating song for group: %s, song: %s", song.Group, song.Song)
 
 	query := `
 		INSERT INTO songs ("group", song, release_date, text, link)
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, "group", song, release_date, text, link
+		RETURNING id, "group", song, release_date, text, link, version
 	`
 
+	created := &internal.VersionedSong{Song: song}
 	err := p.db.QueryRow(query, song.Group, song.Song, song.ReleaseDate, song.Text, song.Link).Scan(
 		&song.Id,
 		&song.Group,
@@ -135,18 +389,24 @@ func (p *PostgresRepository) CreateSong(song *openapi.Song) (*openapi.Song, erro
 		&song.ReleaseDate,
 		&song.Text,
 		&song.Link,
+		&created.Version,
 	)
 
+	if err == nil {
+		created.Slug, err = assignSlug(p.db, song.Id, song.Group, song.Song)
+	}
 	if err != nil {
 		p.logger.Errorf("failed to create song: %v", err)
 		return nil, fmt.Errorf("inserting song: %v", err)
 	}
 
 	p.logger.Infof("Successfully created song for group: %s, song: %s", song.Group, song.Song)
-	return song, nil
+	return created, nil
 }
 
-func (p *PostgresRepository) UpdateSong(songID string, req *openapi.UpdateSongBody) (*openapi.Song, error) {
+// UpdateSong applies the changes and bumps the version. With ifVersion set the row is only
+// updated while it is still at that version, so concurrent editors cannot overwrite each other.
+func (p *PostgresRepository) UpdateSong(songID string, req *openapi.UpdateSongBody, ifVersion *int64) (*internal.VersionedSong, error) {
 	p.logger.Debugf("Updating song with ID: %s", songID)
 	var args []any
 	var fields []string
@@ -181,43 +441,86 @@ func (p *PostgresRepository) UpdateSong(songID string, req *openapi.UpdateSongBo
 	if len(fields) == 0 {
 		return nil, fmt.Errorf("no fields to update")
 	}
+	fields = append(fields, `version = version + 1`)
+
+	where := fmt.Sprintf(`id = $%d`, argID)
+	args = append(args, songID)
+	if ifVersion != nil {
+		args = append(args, *ifVersion)
+		where += fmt.Sprintf(` AND version = $%d`, len(args))
+	}
 
 	query := fmt.Sprintf(`
 		UPDATE songs
 		SET %s
-		WHERE id = $%d
-		RETURNING id, COALESCE("group", ''), COALESCE(song, ''), COALESCE(release_date, ''), COALESCE(text, ''), COALESCE(link, '')
-	`, strings.Join(fields, ", "), argID)
-
-	args = append(args, songID)
+		WHERE %s
+		RETURNING id, COALESCE("group", ''), COALESCE(song, ''), COALESCE(release_date, ''), COALESCE(text, ''), COALESCE(link, ''), version,
+		          COALESCE(artist_slug, ''), COALESCE(slug, '')
+	`, strings.Join(fields, ", "), where)
 
-	var updatedSong openapi.Song
+	updatedSong := &internal.VersionedSong{Song: &openapi.Song{}}
 	err := p.db.QueryRow(query, args...).Scan(
-		&updatedSong.Id,
-		&updatedSong.Group,
-		&updatedSong.Song,
-		&updatedSong.ReleaseDate,
-		&updatedSong.Text,
-		&updatedSong.Link,
+		&updatedSong.Song.Id,
+		&updatedSong.Song.Group,
+		&updatedSong.Song.Song,
+		&updatedSong.Song.ReleaseDate,
+		&updatedSong.Song.Text,
+		&updatedSong.Song.Link,
+		&updatedSong.Version,
+		&updatedSong.Slug.Artist,
+		&updatedSong.Slug.Song,
 	)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = p.missingSongError(songID)
+	}
+	// A rename moves the song to new slugs; the former ones keep leading to it.
+	if err == nil && (req.Group != nil || req.Song != nil) {
+		updatedSong.Slug, err = assignSlug(p.db, songID, updatedSong.Song.Group, updatedSong.Song.Song)
+	}
 	if err != nil {
 		p.logger.Errorf("failed to update song: %v", err)
-		return nil, fmt.Errorf("updating song: %v", err)
+		return nil, fmt.Errorf("updating song: %w", err)
 	}
 
 	p.logger.Infof("Successfully updated song with ID: %s", songID)
-	return &updatedSong, nil
+	return updatedSong, nil
 }
 
-func (p *PostgresRepository) DeleteSong(songID string) error {
+// DeleteSong removes the song, only while it is still at ifVersion when that is set. It returns
+// the artist of the deleted song, for the event describing the deletion.
+func (p *PostgresRepository) DeleteSong(songID string, ifVersion *int64) (string, error) {
 	p.logger.Debugf("Deleting song with ID: %s", songID)
-	_, err := p.db.Exec("DELETE FROM songs WHERE id = $1", songID)
+	query := "DELETE FROM songs WHERE id = $1"
+	args := []any{songID}
+	if ifVersion != nil {
+		query += " AND version = $2"
+		args = append(args, *ifVersion)
+	}
+
+	var group string
+	err := p.db.QueryRow(query+` RETURNING COALESCE("group", '')`, args...).Scan(&group)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = p.missingSongError(songID)
+	}
 	if err != nil {
 		p.logger.Errorf("failed to delete song: %v", err)
-		return fmt.Errorf("deleting song: %v", err)
+		return "", fmt.Errorf("deleting song: %w", err)
 	}
 
 	p.logger.Infof("Successfully deleted song with ID: %s", songID)
-	return nil
+	return group, nil
+}
+
+// missingSongError tells apart a conditional write that matched no row because the song
+// is gone from one that lost the race against another writer.
+func (p *PostgresRepository) missingSongError(songID string) error {
+	var exists bool
+	if err := p.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1)`, songID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return internal.ErrVersionMismatch
+	}
+	return internal.ErrSongNotFound
 }
@@ -0,0 +1,166 @@
+package postgresql
+
+import (
+	"effectiveMobile/internal"
+	"fmt"
+	"maps"
+	"slices"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+)
+
+// searchConfigs maps search languages to text search configurations and their tsvector columns, see migration 000004.
+var searchConfigs = map[string]struct{ config, column string }{
+	"ru": {config: "russian", column: "search_ru"},
+	"en": {config: "english", column: "search_en"},
+}
+
+const (
+	headlineOptions = `StartSel=<mark>, StopSel=</mark>, HighlightAll=true`
+	snippetOptions  = `StartSel=<mark>, StopSel=</mark>, MinWords=5, MaxWords=20`
+)
+
+// SearchSongs ranks songs matching the query; title, artist and lyrics are weighted A, B and C.
+func (p *PostgresRepository) SearchSongs(q *internal.SearchQuery) ([]*internal.SearchHit, []internal.Cursor, error) {
+	p.logger.Debugf("Searching songs for: %s", q.Text)
+
+	lang, ok := searchConfigs[q.Language]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported search language %q", q.Language)
+	}
+	rank := fmt.Sprintf("ts_rank(%s, q)", lang.column)
+
+	sortColumns := maps.Clone(songSortColumns)
+	sortColumns["relevance"] = rank
+	terms, err := sortTerms(q.Sort, sortColumns)
+	if err != nil {
+		p.logger.Errorf("failed to build search order: %v", err)
+		return nil, nil, fmt.Errorf("building order: %v", err)
+	}
+
+	where := fmt.Sprintf(" WHERE %s @@ q", lang.column)
+	params := []any{q.Text}
+	backward := q.Cursor != nil && q.Cursor.Backward
+	if q.Cursor != nil {
+		var keyset string
+		if keyset, params, err = keysetCondition(terms, q.Cursor, params); err != nil {
+			p.logger.Errorf("failed to apply cursor: %v", err)
+			return nil, nil, fmt.Errorf("applying cursor: %v", err)
+		}
+		where += keyset
+	}
+
+	columns, scanDest := selectSongFields(q.Fields)
+	query := fmt.Sprintf(`SELECT %s, %s%s
+		FROM songs, websearch_to_tsquery('%s', $1) AS q`, columns, rank, selectSortValues(terms), lang.config) +
+		where + orderBy(terms, backward)
+	if q.Limit != nil {
+		query += fmt.Sprintf(" LIMIT %d", *q.Limit)
+	}
+
+	rows, err := p.db.Query(query, params...)
+	if err != nil {
+		p.logger.Errorf("failed to search songs: %v", err)
+		return nil, nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	sortName := internal.FormatSort(q.Sort)
+	var hits []*internal.SearchHit
+	var cursors []internal.Cursor
+	for rows.Next() {
+		song := &internal.VersionedSong{Song: &openapi.Song{}}
+		hit := &internal.SearchHit{}
+		values := make([]any, len(terms)-1)
+		dest := append(scanDest(song), &hit.Rank)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err = rows.Scan(dest...); err != nil {
+			p.logger.Errorf("failed to scan search hit: %v", err)
+			return nil, nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		hit.Song = internal.NewSongView(song, q.Fields)
+		hits = append(hits, hit)
+		cursors = append(cursors, internal.Cursor{Sort: sortName, Values: values, ID: song.Song.Id})
+	}
+
+	if err = rows.Err(); err != nil {
+		p.logger.Errorf("error reading rows: %v", err)
+		return nil, nil, fmt.Errorf("row iteration error: %v", err)
+	}
+
+	if backward {
+		slices.Reverse(hits)
+		slices.Reverse(cursors)
+	}
+
+	if err = p.highlight(hits, q.Text, lang.config); err != nil {
+		return nil, nil, err
+	}
+
+	p.logger.Infof("Successfully found %d songs", len(hits))
+	return hits, cursors, nil
+}
+
+// highlight fills in the marked-up title, artist and first matching verse of each hit.
+// It runs after paging so ts_headline is only computed for the returned rows.
+func (p *PostgresRepository) highlight(hits []*internal.SearchHit, text, config string) error {
+	if len(hits) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*internal.SearchHit, len(hits))
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		byID[hit.Song.Id] = hit
+		ids[i] = hit.Song.Id
+	}
+
+	// Verses are split the same way as in GetSongText so the index can be used to fetch them.
+	query := fmt.Sprintf(`
+		SELECT s.id,
+		       ts_headline('%[1]s', COALESCE(s."group", ''), q, $3),
+		       ts_headline('%[1]s', COALESCE(s.song, ''), q, $3),
+		       m.idx, m.snippet
+		FROM songs s
+		CROSS JOIN websearch_to_tsquery('%[1]s', $1) AS q
+		LEFT JOIN LATERAL (
+			SELECT v.idx, ts_headline('%[1]s', v.verse, q, $4) AS snippet
+			FROM (
+				SELECT verse, row_number() OVER (ORDER BY ord) - 1 AS idx
+				FROM regexp_split_to_table(COALESCE(s."text", ''), '\\n\\n') WITH ORDINALITY AS t(verse, ord)
+				WHERE btrim(verse, E' \t\r\n') <> ''
+			) v
+			WHERE to_tsvector('%[1]s', v.verse) @@ q
+			ORDER BY v.idx
+			LIMIT 1
+		) m ON true
+		WHERE s.id = ANY($2)
+	`, config)
+
+	rows, err := p.db.Query(query, text, ids, headlineOptions, snippetOptions)
+	if err != nil {
+		p.logger.Errorf("failed to highlight search hits: %v", err)
+		return fmt.Errorf("highlighting hits: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var h internal.SearchHighlight
+		if err = rows.Scan(&id, &h.Group, &h.Song, &h.Verse, &h.Snippet); err != nil {
+			p.logger.Errorf("failed to scan highlight: %v", err)
+			return fmt.Errorf("failed to scan row: %v", err)
+		}
+		if hit, ok := byID[id]; ok {
+			hit.Highlight = h
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		p.logger.Errorf("error reading rows: %v", err)
+		return fmt.Errorf("row iteration error: %v", err)
+	}
+	return nil
+}
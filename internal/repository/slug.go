@@ -0,0 +1,108 @@
+package postgresql
+
+import (
+	"effectiveMobile/internal"
+	"effectiveMobile/pkg/storage/postgres"
+	"errors"
+	"fmt"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetSong returns the song with the ID, limited to the selected fields.
+func (p *PostgresRepository) GetSong(songID string, fields []string) (*internal.VersionedSong, error) {
+	p.logger.Debugf("Getting song with ID: %s", songID)
+	return p.getSong(`WHERE id = $1`, fields, songID)
+}
+
+// GetSongBySlug returns the song addressed by the slugs, current or former, limited to the
+// selected fields. The slug of the returned song is always the current one.
+func (p *PostgresRepository) GetSongBySlug(artistSlug, slug string, fields []string) (*internal.VersionedSong, error) {
+	p.logger.Debugf("Getting song with slug: %s/%s", artistSlug, slug)
+	return p.getSong(`WHERE id = (SELECT song_id FROM song_slugs WHERE artist_slug = $1 AND slug = $2)`, fields, artistSlug, slug)
+}
+
+// getSong reads the single song matching where, or returns ErrSongNotFound.
+func (p *PostgresRepository) getSong(where string, fields []string, args ...any) (*internal.VersionedSong, error) {
+	columns, scanDest := selectSongFields(fields)
+	song := &internal.VersionedSong{Song: &openapi.Song{}}
+	err := p.db.QueryRow(`SELECT `+columns+` FROM songs `+where, args...).Scan(scanDest(song)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, internal.ErrSongNotFound
+	}
+	if err != nil {
+		p.logger.Errorf("failed to get song: %v", err)
+		return nil, fmt.Errorf("getting song: %v", err)
+	}
+
+	p.logger.Infof("Successfully fetched song with ID: %s", song.Song.Id)
+	return song, nil
+}
+
+// AssignMissingSlugs gives a slug to every song without one, such as those written before slugs
+// existed. It returns how many songs got one.
+func (p *PostgresRepository) AssignMissingSlugs() (int, error) {
+	p.logger.Debug("Assigning missing song slugs")
+	var names []internal.SongName
+	err := p.db.Select(&names, `SELECT id, COALESCE("group", '') AS "group", COALESCE(song, '') AS song FROM songs WHERE slug IS NULL`)
+	if err != nil {
+		p.logger.Errorf("failed to find songs without slug: %v", err)
+		return 0, fmt.Errorf("finding songs without slug: %v", err)
+	}
+
+	for _, name := range names {
+		if _, err = assignSlug(p.db, name.ID, name.Group, name.Song); err != nil {
+			p.logger.Errorf("failed to assign song slug: %v", err)
+			return 0, err
+		}
+	}
+
+	p.logger.Infof("Successfully assigned %d song slugs", len(names))
+	return len(names), nil
+}
+
+// assignSlug makes the slugs of the artist and title the current address of the song. A slug
+// held by another song, even a former one, is never taken over: the title slug gets a number
+// instead, e.g. intro-2. Former slugs of the song stay in song_slugs, so they keep leading to it.
+func assignSlug(db postgres.Postgres, songID, group, title string) (internal.SongSlug, error) {
+	slug := internal.SongSlug{Artist: internal.Slugify(group)}
+	base := internal.Slugify(title)
+	for n := 1; ; n++ {
+		slug.Song = base
+		if n > 1 {
+			slug.Song = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		// The SELECT finds the owner when the slug was taken already. A slug taken by a
+		// transaction that committed after this statement started shows neither way; it is
+		// treated as taken by another song.
+		var owner string
+		err := db.QueryRow(`
+			WITH claimed AS (
+				INSERT INTO song_slugs (artist_slug, slug, song_id) VALUES ($1, $2, $3)
+				ON CONFLICT (artist_slug, slug) DO NOTHING
+				RETURNING song_id::text
+			)
+			SELECT song_id FROM claimed
+			UNION ALL
+			SELECT song_id::text FROM song_slugs WHERE artist_slug = $1 AND slug = $2
+			LIMIT 1
+		`, slug.Artist, slug.Song, songID).Scan(&owner)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return slug, fmt.Errorf("claiming slug %s: %v", slug.Path(), err)
+		}
+		if owner == songID {
+			break
+		}
+	}
+
+	_, err := db.Exec(`UPDATE songs SET artist_slug = $1, slug = $2 WHERE id = $3`, slug.Artist, slug.Song, songID)
+	if err != nil {
+		return slug, fmt.Errorf("setting slug of song %s: %v", songID, err)
+	}
+	return slug, nil
+}
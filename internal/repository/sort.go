@@ -0,0 +1,84 @@
+package postgresql
+
+import (
+	"effectiveMobile/internal"
+	"fmt"
+	"strings"
+)
+
+// songSortColumns maps sortable API fields to SQL expressions.
+// Every expression is backed by a (expression, id) index, see migration 000003.
+var songSortColumns = map[string]string{
+	"id":          `id`,
+	"group":       `COALESCE("group", '')`,
+	"song":        `COALESCE(song, '')`,
+	"releaseDate": `COALESCE(release_date_sort, '')`,
+}
+
+type sortTerm struct {
+	expr string
+	desc bool
+}
+
+// sortTerms resolves the requested keys and appends id as the tie-breaker so the order is total.
+func sortTerms(keys []internal.SortKey, columns map[string]string) ([]sortTerm, error) {
+	terms := make([]sortTerm, 0, len(keys)+1)
+	for _, key := range keys {
+		expr, ok := columns[key.Field]
+		if !ok {
+			return nil, fmt.Errorf("unsupported sort field %q", key.Field)
+		}
+		if key.Field == "id" {
+			return append(terms, sortTerm{expr: expr, desc: key.Desc}), nil
+		}
+		terms = append(terms, sortTerm{expr: expr, desc: key.Desc})
+	}
+	return append(terms, sortTerm{expr: "id"}), nil
+}
+
+// orderBy renders the ORDER BY clause, flipping every direction for backward pages.
+func orderBy(terms []sortTerm, backward bool) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		dir := "ASC"
+		if term.desc != backward {
+			dir = "DESC"
+		}
+		parts[i] = term.expr + " " + dir
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// selectSortValues lists the sort expressions except the id tie-breaker, which is always selected.
+func selectSortValues(terms []sortTerm) string {
+	var b strings.Builder
+	for _, term := range terms[:len(terms)-1] {
+		b.WriteString(", ")
+		b.WriteString(term.expr)
+	}
+	return b.String()
+}
+
+// keysetCondition renders the row comparison that selects rows after (or before) the cursor.
+// Mixed directions rule out a plain row-value comparison, so it expands to
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... with the operator flipped per direction.
+func keysetCondition(terms []sortTerm, cursor *internal.Cursor, params []any) (string, []any, error) {
+	values := append(append([]any{}, cursor.Values...), cursor.ID)
+	if len(values) != len(terms) {
+		return "", nil, internal.ErrInvalidCursor
+	}
+
+	var branches []string
+	var equal []string
+	for i, term := range terms {
+		params = append(params, values[i])
+		op := ">"
+		if term.desc != cursor.Backward {
+			op = "<"
+		}
+		branch := append(append([]string{}, equal...), fmt.Sprintf("%s %s $%d", term.expr, op, len(params)))
+		branches = append(branches, "("+strings.Join(branch, " AND ")+")")
+		equal = append(equal, fmt.Sprintf("%s = $%d", term.expr, len(params)))
+	}
+	return " AND (" + strings.Join(branches, " OR ") + ")", params, nil
+}
@@ -0,0 +1,154 @@
+package postgresql
+
+import (
+	"effectiveMobile/internal"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func mustSortTerms(t *testing.T, sort string) []sortTerm {
+	t.Helper()
+	keys, err := internal.ParseSort(sort, internal.SongSortFields)
+	if err != nil {
+		t.Fatalf("ParseSort(%q) error = %v", sort, err)
+	}
+	terms, err := sortTerms(keys, songSortColumns)
+	if err != nil {
+		t.Fatalf("sortTerms(%q) error = %v", sort, err)
+	}
+	return terms
+}
+
+func TestSortTerms(t *testing.T) {
+	tests := []struct {
+		sort string
+		want []sortTerm
+	}{
+		{sort: "", want: []sortTerm{{expr: "id"}}},
+		{sort: "group", want: []sortTerm{{expr: `COALESCE("group", '')`}, {expr: "id"}}},
+		{sort: "-releaseDate,song", want: []sortTerm{
+			{expr: `COALESCE(release_date_sort, '')`, desc: true},
+			{expr: `COALESCE(song, '')`},
+			{expr: "id"},
+		}},
+		// id is unique, so it ends the order and later keys are dropped.
+		{sort: "-id,group", want: []sortTerm{{expr: "id", desc: true}}},
+		{sort: "group,-id", want: []sortTerm{{expr: `COALESCE("group", '')`}, {expr: "id", desc: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			if got := mustSortTerms(t, tt.sort); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("sortTerms(%q) = %v, want %v", tt.sort, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := sortTerms([]internal.SortKey{{Field: "text"}}, songSortColumns); err == nil {
+		t.Fatal("sortTerms(text) succeeded, want an error for an unsupported field")
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	terms := mustSortTerms(t, "-releaseDate,group")
+	const forward = ` ORDER BY COALESCE(release_date_sort, '') DESC, COALESCE("group", '') ASC, id ASC`
+	const backward = ` ORDER BY COALESCE(release_date_sort, '') ASC, COALESCE("group", '') DESC, id DESC`
+	if got := orderBy(terms, false); got != forward {
+		t.Errorf("orderBy(forward) = %q, want %q", got, forward)
+	}
+	if got := orderBy(terms, true); got != backward {
+		t.Errorf("orderBy(backward) = %q, want %q", got, backward)
+	}
+	if got, want := selectSortValues(terms), `, COALESCE(release_date_sort, ''), COALESCE("group", '')`; got != want {
+		t.Errorf("selectSortValues = %q, want %q", got, want)
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		sort       string
+		cursor     internal.Cursor
+		params     []any
+		want       string
+		wantParams []any
+	}{
+		{
+			name:       "default order",
+			cursor:     internal.Cursor{ID: "u1"},
+			params:     []any{"%Muse%"},
+			want:       ` AND ((id > $2))`,
+			wantParams: []any{"%Muse%", "u1"},
+		},
+		{
+			name:       "default order backward",
+			cursor:     internal.Cursor{ID: "u1", Backward: true},
+			want:       ` AND ((id < $1))`,
+			wantParams: []any{"u1"},
+		},
+		{
+			name:       "descending id",
+			sort:       "-id",
+			cursor:     internal.Cursor{ID: "u1"},
+			want:       ` AND ((id < $1))`,
+			wantParams: []any{"u1"},
+		},
+		{
+			name:   "mixed directions",
+			sort:   "-releaseDate,group",
+			cursor: internal.Cursor{Values: []any{"20060716", "Muse"}, ID: "u1"},
+			want: ` AND ((COALESCE(release_date_sort, '') < $1)` +
+				` OR (COALESCE(release_date_sort, '') = $1 AND COALESCE("group", '') > $2)` +
+				` OR (COALESCE(release_date_sort, '') = $1 AND COALESCE("group", '') = $2 AND id > $3))`,
+			wantParams: []any{"20060716", "Muse", "u1"},
+		},
+		{
+			name:   "mixed directions backward",
+			sort:   "-releaseDate,group",
+			cursor: internal.Cursor{Values: []any{"20060716", "Muse"}, ID: "u1", Backward: true},
+			want: ` AND ((COALESCE(release_date_sort, '') > $1)` +
+				` OR (COALESCE(release_date_sort, '') = $1 AND COALESCE("group", '') < $2)` +
+				` OR (COALESCE(release_date_sort, '') = $1 AND COALESCE("group", '') = $2 AND id < $3))`,
+			wantParams: []any{"20060716", "Muse", "u1"},
+		},
+		{
+			// NULL sorts as '' through COALESCE, so a cursor on a row without a value compares to ''.
+			name:   "null value",
+			sort:   "song",
+			cursor: internal.Cursor{Values: []any{""}, ID: "u1"},
+			params: []any{"%a%", "%b%"},
+			want: ` AND ((COALESCE(song, '') > $3)` +
+				` OR (COALESCE(song, '') = $3 AND id > $4))`,
+			wantParams: []any{"%a%", "%b%", "", "u1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, params, err := keysetCondition(mustSortTerms(t, tt.sort), &tt.cursor, tt.params)
+			if err != nil {
+				t.Fatalf("keysetCondition error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("keysetCondition =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("keysetCondition params = %v, want %v", params, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestKeysetConditionMismatchedCursor(t *testing.T) {
+	tests := map[string]internal.Cursor{
+		"too few values":  {ID: "u1"},
+		"too many values": {Values: []any{"Muse", "Uprising"}, ID: "u1"},
+	}
+	terms := mustSortTerms(t, "group")
+	for name, cursor := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := keysetCondition(terms, &cursor, nil); !errors.Is(err, internal.ErrInvalidCursor) {
+				t.Fatalf("keysetCondition error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
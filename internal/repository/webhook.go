@@ -0,0 +1,183 @@
+package postgresql
+
+import (
+	"effectiveMobile/internal"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, next_attempt_at, last_status, last_error, created_at, delivered_at`
+
+// CreateWebhook stores the subscription and fills in its ID and creation time.
+func (p *PostgresRepository) CreateWebhook(webhook *internal.Webhook) error {
+	p.logger.Debugf("Creating webhook for URL: %s", webhook.URL)
+	err := p.db.QueryRow(`INSERT INTO webhooks (url, events, secret) VALUES ($1, $2, $3) RETURNING id, created_at`,
+		webhook.URL, webhook.Events, webhook.Secret).Scan(&webhook.ID, &webhook.CreatedAt)
+	if err != nil {
+		p.logger.Errorf("failed to create webhook: %v", err)
+		return fmt.Errorf("creating webhook: %v", err)
+	}
+	return nil
+}
+
+// GetWebhooks lists the subscriptions without their secrets.
+func (p *PostgresRepository) GetWebhooks() ([]*internal.Webhook, error) {
+	p.logger.Debug("Getting webhooks")
+	rows, err := p.db.Query(`SELECT id, url, events, created_at FROM webhooks ORDER BY created_at, id`)
+	if err != nil {
+		p.logger.Errorf("failed to get webhooks: %v", err)
+		return nil, fmt.Errorf("getting webhooks: %v", err)
+	}
+	defer rows.Close()
+
+	webhooks := []*internal.Webhook{}
+	for rows.Next() {
+		webhook := &internal.Webhook{}
+		if err = rows.Scan(&webhook.ID, &webhook.URL, &webhook.Events, &webhook.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning webhook: %v", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook removes the subscription along with its deliveries.
+func (p *PostgresRepository) DeleteWebhook(webhookID string) error {
+	p.logger.Debugf("Deleting webhook with ID: %s", webhookID)
+	tag, err := p.db.Exec(`DELETE FROM webhooks WHERE id::text = $1`, webhookID)
+	if err != nil {
+		p.logger.Errorf("failed to delete webhook: %v", err)
+		return fmt.Errorf("deleting webhook: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return internal.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// EnqueueWebhookDeliveries schedules a delivery of the payload to every webhook subscribed to
+// the event and returns how many were scheduled.
+func (p *PostgresRepository) EnqueueWebhookDeliveries(event string, payload []byte) (int64, error) {
+	tag, err := p.db.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT id, $1, $2 FROM webhooks WHERE $1 = ANY(events)
+	`, event, payload)
+	if err != nil {
+		p.logger.Errorf("failed to enqueue webhook deliveries: %v", err)
+		return 0, fmt.Errorf("enqueueing webhook deliveries: %v", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ClaimWebhookDeliveries takes up to limit pending deliveries that are due, along with the URL
+// and secret of their webhook. They are leased by moving their next attempt past lease, so other
+// replicas skip them, and a delivery whose sender dies is picked up again once the lease is over.
+func (p *PostgresRepository) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]*internal.WebhookDelivery, error) {
+	rows, err := p.db.Query(`
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at,
+		          d.last_status, d.last_error, d.created_at, d.delivered_at, w.url, w.secret
+	`, limit, lease.Seconds())
+	if err != nil {
+		p.logger.Errorf("failed to claim webhook deliveries: %v", err)
+		return nil, fmt.Errorf("claiming webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*internal.WebhookDelivery
+	for rows.Next() {
+		delivery := &internal.WebhookDelivery{}
+		if err = rows.Scan(append(deliveryFields(delivery), &delivery.URL, &delivery.Secret)...); err != nil {
+			return nil, fmt.Errorf("scanning webhook delivery: %v", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// SaveWebhookAttempt stores the outcome of the latest attempt of a delivery.
+func (p *PostgresRepository) SaveWebhookAttempt(delivery *internal.WebhookDelivery) error {
+	_, err := p.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_status = $5, last_error = $6, delivered_at = $7
+		WHERE id = $1
+	`, delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatus, delivery.LastError, delivery.DeliveredAt)
+	if err != nil {
+		p.logger.Errorf("failed to save webhook attempt: %v", err)
+		return fmt.Errorf("saving webhook attempt: %v", err)
+	}
+	return nil
+}
+
+// GetWebhookDeliveries lists the latest deliveries of a webhook, optionally with the given status only.
+func (p *PostgresRepository) GetWebhookDeliveries(webhookID string, status internal.DeliveryStatus, limit int) ([]*internal.WebhookDelivery, error) {
+	p.logger.Debugf("Getting deliveries of webhook with ID: %s", webhookID)
+	var exists bool
+	if err := p.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM webhooks WHERE id::text = $1)`, webhookID).Scan(&exists); err != nil {
+		p.logger.Errorf("failed to get webhook: %v", err)
+		return nil, fmt.Errorf("getting webhook: %v", err)
+	}
+	if !exists {
+		return nil, internal.ErrWebhookNotFound
+	}
+
+	rows, err := p.db.Query(`
+		SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id::text = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id
+		LIMIT $3
+	`, webhookID, string(status), limit)
+	if err != nil {
+		p.logger.Errorf("failed to get webhook deliveries: %v", err)
+		return nil, fmt.Errorf("getting webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*internal.WebhookDelivery{}
+	for rows.Next() {
+		delivery := &internal.WebhookDelivery{}
+		if err = rows.Scan(deliveryFields(delivery)...); err != nil {
+			return nil, fmt.Errorf("scanning webhook delivery: %v", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// RedeliverWebhookDelivery schedules a delivery to be sent again right away with a fresh set of
+// attempts, whatever its status.
+func (p *PostgresRepository) RedeliverWebhookDelivery(webhookID, deliveryID string) (*internal.WebhookDelivery, error) {
+	p.logger.Debugf("Redelivering webhook delivery with ID: %s", deliveryID)
+	delivery := &internal.WebhookDelivery{}
+	err := p.db.QueryRow(`
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = NULL
+		WHERE id::text = $2 AND webhook_id::text = $1
+		RETURNING `+deliveryColumns, webhookID, deliveryID).Scan(deliveryFields(delivery)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, internal.ErrDeliveryNotFound
+	}
+	if err != nil {
+		p.logger.Errorf("failed to redeliver webhook delivery: %v", err)
+		return nil, fmt.Errorf("redelivering webhook delivery: %v", err)
+	}
+	return delivery, nil
+}
+
+// deliveryFields returns the scan targets of deliveryColumns.
+func deliveryFields(d *internal.WebhookDelivery) []any {
+	return []any{&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt}
+}
@@ -3,16 +3,50 @@
 package internal
 
 import (
+	"context"
+	"effectiveMobile/pkg/publisher"
+	"io"
+
 	openapi "github.com/Lineblaze/effective_mobile_gen"
 )
 
 // Controller describes methods, implemented by the usecase package.
 type UseCase interface {
+	CreateSongs(mode BatchMode, items []openapi.CreateSongBody) (*BatchResponse, error)
+	UpdateSongs(mode BatchMode, items []SongUpdate) (*BatchResponse, error)
+	DeleteSongs(mode BatchMode, items []SongDelete) (*BatchResponse, error)
+	SubscribeSongEvents(filter EventFilter, lastEventID int64) (<-chan *SongEvent, func())
+	RunEventFeed()
+	PurgeSongEvents() error
+	BeginIdempotent(key, fingerprint string) (*IdempotentResponse, error)
+	CompleteIdempotent(key string, resp *IdempotentResponse) error
+	ReleaseIdempotent(key string) error
+	PurgeIdempotencyKeys() error
+	ImportSongs(r io.Reader, opts ImportOptions) (*ImportReport, error)
+	GetImportReport(importID string) (*ImportReport, error)
+	GetArtists(names []string) ([]*Artist, error)
+	RunOutboxRelay(pub publisher.Publisher)
+	GetOutboxStats() (*OutboxStats, error)
+	GetSongBySlug(artistSlug, slug string, fields, include []string) (*SongView, error)
+	AssignMissingSlugs() error
+	WithContext(ctx context.Context) UseCase
 	FetchSongDetail(group, song string) (*openapi.SongDetail, error)
 	GetSongDetail(group, song string) (*openapi.SongDetail, error)
-	GetSongs(body *openapi.GetSongsBody) ([]*openapi.Song, error)
-	GetSongText(body *openapi.GetSongTextBody) ([][]string, error)
-	CreateSong(req openapi.CreateSongBody, detail *openapi.SongDetail) (*openapi.Song, error)
-	UpdateSong(songID string, body *openapi.UpdateSongBody) (*openapi.Song, error)
-	DeleteSong(songID string) error
+	GetSongDetails(keys []SongKey) (map[SongKey]*openapi.SongDetail, error)
+	GetSongs(q *SongsQuery) (*SongsPage, error)
+	GetSong(songID string, fields, include []string) (*SongView, error)
+	ExportSongs(q *SongsQuery, fn func(song *SongView) error) error
+	GetSongText(q *TextQuery) (*SongText, error)
+	CreateSong(req openapi.CreateSongBody, detail *openapi.SongDetail) (*VersionedSong, error)
+	UpdateSong(songID string, body *openapi.UpdateSongBody, ifVersion *int64) (*VersionedSong, error)
+	DeleteSong(songID string, ifVersion *int64) error
+	SearchSongs(q *SearchQuery) (*SearchPage, error)
+	Suggest(query string, limit int) []Suggestion
+	RebuildSuggestions() error
+	CreateWebhook(target string, events []string) (*Webhook, error)
+	GetWebhooks() ([]*Webhook, error)
+	DeleteWebhook(webhookID string) error
+	GetWebhookDeliveries(webhookID string, status DeliveryStatus, limit int) ([]*WebhookDelivery, error)
+	RedeliverWebhook(webhookID, deliveryID string) (*WebhookDelivery, error)
+	RunWebhooks()
 }
@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	repository "effectiveMobile/internal"
+	"errors"
+	"fmt"
+	"sync"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+)
+
+// errBatchAborted stops a transactional batch at its first failing item.
+var errBatchAborted = errors.New("batch aborted")
+
+// CreateSongs creates the songs, fetching their details concurrently beforehand.
+func (u *UseCase) CreateSongs(mode repository.BatchMode, items []openapi.CreateSongBody) (*repository.BatchResponse, error) {
+	u.logger.Debugf("Creating batch of %d songs in %s mode", len(items), mode)
+	if err := u.checkBatch(mode, len(items)); err != nil {
+		return nil, err
+	}
+
+	results := newBatchResults(len(items))
+	details := make([]*openapi.SongDetail, len(items))
+	parallel(len(items), u.batchWorkers, func(i int) {
+		if items[i].Group == "" || items[i].Song == "" {
+			results[i].Err = fmt.Errorf("%w: group and song are required", repository.ErrInvalidItem)
+			return
+		}
+		detail, err := u.FetchSongDetail(items[i].Group, items[i].Song)
+		if err != nil {
+			results[i].Err = fmt.Errorf("%w: %v", repository.ErrDetailUnavailable, err)
+			return
+		}
+		details[i] = detail
+	})
+
+	committed, err := u.runBatch(mode, results, func(repo repository.Repository, i int) error {
+		song := &openapi.Song{
+			Group:       items[i].Group,
+			Song:        items[i].Song,
+			ReleaseDate: details[i].ReleaseDate,
+			Text:        details[i].Text,
+			Link:        details[i].Link,
+		}
+		created, err := repo.CreateSong(song)
+		if err != nil {
+			return err
+		}
+		results[i].Id, results[i].Version, results[i].Song = created.Song.Id, created.Version, created.Song
+		return u.saveEvent(repo, repository.EventSongCreated, created.Song.Id, created.Song.Group, created.Version, created.Song)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Err == nil {
+			u.suggestions.put(repository.SongName{ID: result.Song.Id, Group: result.Song.Group, Song: result.Song.Song})
+		}
+	}
+	return u.batchResponse(mode, committed, results), nil
+}
+
+// UpdateSongs applies the changes, honouring each item's expected version.
+func (u *UseCase) UpdateSongs(mode repository.BatchMode, items []repository.SongUpdate) (*repository.BatchResponse, error) {
+	u.logger.Debugf("Updating batch of %d songs in %s mode", len(items), mode)
+	if err := u.checkBatch(mode, len(items)); err != nil {
+		return nil, err
+	}
+
+	results := newBatchResults(len(items))
+	for i, item := range items {
+		results[i].Id = item.ID
+		changes := item.Changes
+		if item.ID == "" || (changes.Group == nil && changes.Song == nil && changes.ReleaseDate == nil && changes.Text == nil && changes.Link == nil) {
+			results[i].Err = fmt.Errorf("%w: id and at least one change are required", repository.ErrInvalidItem)
+		}
+	}
+
+	committed, err := u.runBatch(mode, results, func(repo repository.Repository, i int) error {
+		updated, err := repo.UpdateSong(items[i].ID, &items[i].Changes, items[i].IfVersion)
+		if err != nil {
+			return err
+		}
+		results[i].Version, results[i].Song = updated.Version, updated.Song
+		return u.saveEvent(repo, repository.EventSongUpdated, updated.Song.Id, updated.Song.Group, updated.Version, updated.Song)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Err == nil {
+			u.suggestions.put(repository.SongName{ID: result.Song.Id, Group: result.Song.Group, Song: result.Song.Song})
+		}
+	}
+	return u.batchResponse(mode, committed, results), nil
+}
+
+// DeleteSongs removes the songs, honouring each item's expected version.
+func (u *UseCase) DeleteSongs(mode repository.BatchMode, items []repository.SongDelete) (*repository.BatchResponse, error) {
+	u.logger.Debugf("Deleting batch of %d songs in %s mode", len(items), mode)
+	if err := u.checkBatch(mode, len(items)); err != nil {
+		return nil, err
+	}
+
+	results := newBatchResults(len(items))
+	for i, item := range items {
+		results[i].Id = item.ID
+		if item.ID == "" {
+			results[i].Err = fmt.Errorf("%w: id is required", repository.ErrInvalidItem)
+		}
+	}
+
+	committed, err := u.runBatch(mode, results, func(repo repository.Repository, i int) error {
+		group, err := repo.DeleteSong(items[i].ID, items[i].IfVersion)
+		if err != nil {
+			return err
+		}
+		return u.saveEvent(repo, repository.EventSongDeleted, items[i].ID, group, 0, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Err == nil {
+			u.suggestions.remove(result.Id)
+		}
+	}
+	return u.batchResponse(mode, committed, results), nil
+}
+
+func (u *UseCase) checkBatch(mode repository.BatchMode, size int) error {
+	if mode != repository.BatchTransaction && mode != repository.BatchBestEffort {
+		return fmt.Errorf("%w: mode must be %s or %s", repository.ErrInvalidBatch, repository.BatchTransaction, repository.BatchBestEffort)
+	}
+	if size == 0 || size > u.batchMaxItems {
+		return fmt.Errorf("%w: a batch holds 1 to %d items", repository.ErrInvalidBatch, u.batchMaxItems)
+	}
+	return nil
+}
+
+func newBatchResults(n int) []repository.BatchResult {
+	results := make([]repository.BatchResult, n)
+	for i := range results {
+		results[i].Index = i
+	}
+	return results
+}
+
+// runBatch calls apply for every item that has not failed yet, recording failures in results.
+// In transaction mode all items share one transaction: any failure, including one found before
+// the batch started, leaves the database untouched and the other items report ErrRolledBack.
+// In best-effort mode every item has a transaction of its own. It reports whether anything was
+// written.
+func (u *UseCase) runBatch(mode repository.BatchMode, results []repository.BatchResult, apply func(repo repository.Repository, i int) error) (bool, error) {
+	if mode == repository.BatchBestEffort {
+		committed := false
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = u.repo.InTx(func(repo repository.Repository) error {
+					return apply(repo, i)
+				})
+				committed = committed || results[i].Err == nil
+			}
+		}
+		return committed, nil
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			rollBack(results)
+			return false, nil
+		}
+	}
+
+	err := u.repo.InTx(func(repo repository.Repository) error {
+		for i := range results {
+			if err := apply(repo, i); err != nil {
+				results[i].Err = err
+				return errBatchAborted
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchAborted) {
+		rollBack(results)
+		return false, nil
+	}
+	if err != nil {
+		u.logger.Errorf("error committing batch: %v", err)
+		return false, fmt.Errorf("committing batch: %v", err)
+	}
+	return true, nil
+}
+
+// rollBack marks the items that succeeded as undone.
+func rollBack(results []repository.BatchResult) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = repository.ErrRolledBack
+			results[i].Version, results[i].Song = 0, nil
+		}
+	}
+}
+
+func (u *UseCase) batchResponse(mode repository.BatchMode, committed bool, results []repository.BatchResult) *repository.BatchResponse {
+	response := &repository.BatchResponse{Mode: mode, Committed: committed, Results: results}
+	for _, result := range results {
+		if result.Err == nil {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	u.logger.Infof("Batch finished in %s mode: %d succeeded, %d failed", mode, response.Succeeded, response.Failed)
+	return response
+}
+
+// parallel calls fn for every index below n on at most workers goroutines.
+func parallel(n, workers int, fn func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(workers, 1), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"context"
+	"effectiveMobile/config"
+	repository "effectiveMobile/internal"
+	"effectiveMobile/pkg/logger"
+	"errors"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+)
+
+// memRepo keeps song versions in memory. Transactions work on a copy that replaces the
+// original only when they succeed. Methods the tests do not need panic through the nil
+// embedded Repository.
+type memRepo struct {
+	repository.Repository
+	versions map[string]int64
+	events   int
+	groups   []string
+}
+
+func newMemRepo(ids ...string) *memRepo {
+	r := &memRepo{versions: make(map[string]int64)}
+	for _, id := range ids {
+		r.versions[id] = 1
+	}
+	return r
+}
+
+func (r *memRepo) InTx(fn func(repo repository.Repository) error) error {
+	tx := &memRepo{versions: maps.Clone(r.versions), events: r.events, groups: slices.Clone(r.groups)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	r.versions, r.events, r.groups = tx.versions, tx.events, tx.groups
+	return nil
+}
+
+func (r *memRepo) check(songID string, ifVersion *int64) error {
+	version, ok := r.versions[songID]
+	if !ok {
+		return repository.ErrSongNotFound
+	}
+	if ifVersion != nil && *ifVersion != version {
+		return repository.ErrVersionMismatch
+	}
+	return nil
+}
+
+func (r *memRepo) UpdateSong(songID string, req *openapi.UpdateSongBody, ifVersion *int64) (*repository.VersionedSong, error) {
+	if err := r.check(songID, ifVersion); err != nil {
+		return nil, err
+	}
+	r.versions[songID]++
+	song := &openapi.Song{Id: songID, Group: "Muse"}
+	if req.Song != nil {
+		song.Song = *req.Song
+	}
+	return &repository.VersionedSong{Song: song, Version: r.versions[songID]}, nil
+}
+
+func (r *memRepo) DeleteSong(songID string, ifVersion *int64) (string, error) {
+	if err := r.check(songID, ifVersion); err != nil {
+		return "", err
+	}
+	delete(r.versions, songID)
+	return "Muse", nil
+}
+
+func (r *memRepo) SaveOutboxEvent(event *repository.SongEvent) error {
+	r.events++
+	r.groups = append(r.groups, event.Group)
+	return nil
+}
+
+func newTestUseCase(repo repository.Repository) *UseCase {
+	l := logger.NewApiLogger(&config.Config{})
+	_ = l.InitLogger()
+	return &UseCase{repo: repo, logger: l, suggestions: newSuggestions(), batchMaxItems: 4, batchWorkers: 2, ctx: context.Background()}
+}
+
+func version(v int64) *int64 {
+	return &v
+}
+
+func rename(id string, ifVersion *int64) repository.SongUpdate {
+	title := "Renamed"
+	return repository.SongUpdate{ID: id, IfVersion: ifVersion, Changes: openapi.UpdateSongBody{Song: &title}}
+}
+
+func resultErrors(results []repository.BatchResult) []error {
+	errs := make([]error, len(results))
+	for i, result := range results {
+		errs[i] = result.Err
+	}
+	return errs
+}
+
+func checkErrors(t *testing.T, got []error, want []error) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if (want[i] == nil) != (got[i] == nil) || (want[i] != nil && !errors.Is(got[i], want[i])) {
+			t.Errorf("item %d error = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUpdateSongsTransaction(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []repository.SongUpdate
+		committed bool
+		errs      []error
+		versions  map[string]int64
+		events    int
+	}{
+		{
+			name:      "all succeed",
+			items:     []repository.SongUpdate{rename("a", version(1)), rename("b", nil)},
+			committed: true,
+			errs:      []error{nil, nil},
+			versions:  map[string]int64{"a": 2, "b": 2},
+			events:    2,
+		},
+		{
+			name:      "version mismatch rolls back",
+			items:     []repository.SongUpdate{rename("a", nil), rename("b", version(7)), rename("a", nil)},
+			committed: false,
+			errs:      []error{repository.ErrRolledBack, repository.ErrVersionMismatch, repository.ErrRolledBack},
+			versions:  map[string]int64{"a": 1, "b": 1},
+		},
+		{
+			name:      "invalid item fails before writing",
+			items:     []repository.SongUpdate{rename("a", nil), {ID: "b"}},
+			committed: false,
+			errs:      []error{repository.ErrRolledBack, repository.ErrInvalidItem},
+			versions:  map[string]int64{"a": 1, "b": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemRepo("a", "b")
+			resp, err := newTestUseCase(repo).UpdateSongs(repository.BatchTransaction, tt.items)
+			if err != nil {
+				t.Fatalf("UpdateSongs error = %v", err)
+			}
+			if resp.Committed != tt.committed {
+				t.Errorf("Committed = %v, want %v", resp.Committed, tt.committed)
+			}
+			checkErrors(t, resultErrors(resp.Results), tt.errs)
+			if !maps.Equal(repo.versions, tt.versions) || repo.events != tt.events {
+				t.Errorf("stored versions %v and %d events, want %v and %d", repo.versions, repo.events, tt.versions, tt.events)
+			}
+			for _, result := range resp.Results {
+				if errors.Is(result.Err, repository.ErrRolledBack) && (result.Song != nil || result.Version != 0) {
+					t.Errorf("rolled back item %d still reports %+v", result.Index, result)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateSongsBestEffort(t *testing.T) {
+	repo := newMemRepo("a", "b")
+	uc := newTestUseCase(repo)
+	items := []repository.SongUpdate{rename("a", version(1)), rename("b", version(7)), rename("c", nil), {ID: "b"}}
+
+	resp, err := uc.UpdateSongs(repository.BatchBestEffort, items)
+	if err != nil {
+		t.Fatalf("UpdateSongs error = %v", err)
+	}
+	if !resp.Committed || resp.Succeeded != 1 || resp.Failed != 3 {
+		t.Errorf("response = committed %v, %d succeeded, %d failed; want committed, 1 and 3", resp.Committed, resp.Succeeded, resp.Failed)
+	}
+	checkErrors(t, resultErrors(resp.Results), []error{nil, repository.ErrVersionMismatch, repository.ErrSongNotFound, repository.ErrInvalidItem})
+	if want := map[string]int64{"a": 2, "b": 1}; !maps.Equal(repo.versions, want) || repo.events != 1 {
+		t.Errorf("stored versions %v and %d events, want %v and 1", repo.versions, repo.events, want)
+	}
+	if got := uc.suggestions.search("renamed", 10); len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("suggestions after the batch = %+v, want only the updated song", got)
+	}
+
+	resp, err = uc.UpdateSongs(repository.BatchBestEffort, []repository.SongUpdate{rename("c", nil)})
+	if err != nil || resp.Committed {
+		t.Errorf("UpdateSongs with only failing items = %+v, %v, want nothing committed", resp, err)
+	}
+}
+
+func TestDeleteSongs(t *testing.T) {
+	for _, mode := range []repository.BatchMode{repository.BatchTransaction, repository.BatchBestEffort} {
+		t.Run(string(mode), func(t *testing.T) {
+			repo := newMemRepo("a", "b")
+			uc := newTestUseCase(repo)
+			uc.suggestions.reset([]repository.SongName{{ID: "a", Song: "Uprising"}, {ID: "b", Song: "Starlight"}})
+
+			resp, err := uc.DeleteSongs(mode, []repository.SongDelete{{ID: "a"}, {ID: "b", IfVersion: version(2)}})
+			if err != nil {
+				t.Fatalf("DeleteSongs error = %v", err)
+			}
+
+			wantVersions, wantErrs := map[string]int64{"b": 1}, []error{nil, repository.ErrVersionMismatch}
+			if mode == repository.BatchTransaction {
+				wantVersions, wantErrs = map[string]int64{"a": 1, "b": 1}, []error{repository.ErrRolledBack, repository.ErrVersionMismatch}
+			}
+			checkErrors(t, resultErrors(resp.Results), wantErrs)
+			if !maps.Equal(repo.versions, wantVersions) {
+				t.Errorf("stored versions = %v, want %v", repo.versions, wantVersions)
+			}
+			if deleted := len(uc.suggestions.search("uprising", 10)) == 0; deleted != (mode == repository.BatchBestEffort) {
+				t.Errorf("suggestion of song a removed = %v in %s mode", deleted, mode)
+			}
+			// The artist comes from the deleted row, even for songs this replica never indexed.
+			if mode == repository.BatchBestEffort && !slices.Equal(repo.groups, []string{"Muse"}) {
+				t.Errorf("deletion events of artists %q, want [Muse]", repo.groups)
+			}
+		})
+	}
+}
+
+func TestCheckBatch(t *testing.T) {
+	uc := newTestUseCase(newMemRepo())
+	tests := []struct {
+		mode    repository.BatchMode
+		size    int
+		wantErr bool
+	}{
+		{mode: repository.BatchTransaction, size: 1},
+		{mode: repository.BatchBestEffort, size: 4},
+		{mode: repository.BatchTransaction, size: 0, wantErr: true},
+		{mode: repository.BatchBestEffort, size: 5, wantErr: true},
+		{mode: "atomic", size: 1, wantErr: true},
+		{mode: "", size: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := uc.checkBatch(tt.mode, tt.size)
+		if tt.wantErr != errors.Is(err, repository.ErrInvalidBatch) || (!tt.wantErr && err != nil) {
+			t.Errorf("checkBatch(%q, %d) = %v, want error %v", tt.mode, tt.size, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParallel(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 50} {
+		const n = 20
+		var calls [n]atomic.Int32
+		parallel(n, workers, func(i int) { calls[i].Add(1) })
+		for i := range calls {
+			if got := calls[i].Load(); got != 1 {
+				t.Errorf("parallel with %d workers called index %d %d times", workers, i, got)
+			}
+		}
+	}
+	parallel(0, 4, func(int) { t.Error("parallel called fn without items") })
+}
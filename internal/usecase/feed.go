@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"context"
+	repository "effectiveMobile/internal"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// feedBuffer is how many events a subscriber can fall behind before it is dropped.
+	feedBuffer = 256
+	// feedPage is how many stored events are read at once when catching up.
+	feedPage = 500
+	// maxFeedReconnectDelay bounds the wait before listening again after the connection was lost.
+	maxFeedReconnectDelay = 30 * time.Second
+)
+
+// feed pushes the events of the change feed to the subscribers connected to this replica.
+type feed struct {
+	mu          sync.Mutex
+	subscribers map[*subscription]struct{}
+	// lastSeq is the latest event seen, from which the replica catches up after reconnecting.
+	lastSeq int64
+	// caughtUp holds the events read while catching up, whose notifications may still be queued.
+	caughtUp map[int64]bool
+}
+
+type subscription struct {
+	filter repository.EventFilter
+	events chan *repository.SongEvent
+}
+
+func newFeed() *feed {
+	return &feed{subscribers: make(map[*subscription]struct{})}
+}
+
+func (f *feed) subscribe(filter repository.EventFilter) *subscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub := &subscription{filter: filter, events: make(chan *repository.SongEvent, feedBuffer)}
+	f.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (f *feed) unsubscribe(sub *subscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subscribers[sub]; ok {
+		delete(f.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// broadcast hands the event to every matching subscriber. Subscribers whose buffer is full are
+// dropped rather than waited for: their channel is closed and they resume from their last event.
+// It reports false for the notification of an event already pushed while catching up.
+func (f *feed) broadcast(event *repository.SongEvent) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.caughtUp[event.Seq] {
+		delete(f.caughtUp, event.Seq)
+		return false
+	}
+	f.lastSeq = max(f.lastSeq, event.Seq)
+	for sub := range f.subscribers {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(f.subscribers, sub)
+			close(sub.events)
+		}
+	}
+	return true
+}
+
+// SubscribeSongEvents streams the song events matching filter as they happen, on any replica.
+// With lastEventID set, the stored events following it are replayed first. The channel is closed
+// when the subscriber falls too far behind; it can then subscribe again from the last event it
+// received. cancel ends the subscription.
+func (u *UseCase) SubscribeSongEvents(filter repository.EventFilter, lastEventID int64) (<-chan *repository.SongEvent, func()) {
+	sub := u.feed.subscribe(filter)
+	if lastEventID == 0 {
+		return sub.events, func() { u.feed.unsubscribe(sub) }
+	}
+
+	// Live events are buffered by the subscription while the stored ones are replayed.
+	out := make(chan *repository.SongEvent)
+	done := make(chan struct{})
+	go func() {
+		defer close(out)
+		send := func(event *repository.SongEvent) bool {
+			select {
+			case out <- event:
+				return true
+			case <-done:
+				return false
+			}
+		}
+
+		replayed := make(map[int64]bool)
+		for seq := lastEventID; ; {
+			events, err := u.repo.GetSongEventsAfter(seq, feedPage)
+			if err != nil {
+				u.logger.Errorf("error replaying song events: %v", err)
+				u.feed.unsubscribe(sub)
+				return
+			}
+			for _, event := range events {
+				seq, replayed[event.Seq] = event.Seq, true
+				if filter.Match(event) && !send(event) {
+					return
+				}
+			}
+			if len(events) < feedPage {
+				break
+			}
+		}
+		for event := range sub.events {
+			if !replayed[event.Seq] && !send(event) {
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return out, func() {
+		once.Do(func() {
+			close(done)
+			u.feed.unsubscribe(sub)
+		})
+	}
+}
+
+// RunEventFeed pushes the events added to the change feed by the outbox relay, on any replica,
+// to the subscribers of this one until the process exits. The events also keep the suggestion
+// index of this replica in line with the writes made on the others.
+func (u *UseCase) RunEventFeed() {
+	delay := time.Second
+	for {
+		listening := time.Now()
+		err := u.repo.ListenSongEvents(context.Background(), u.catchUp, func(eventID string) {
+			event, err := u.repo.GetSongEvent(eventID)
+			if err != nil {
+				u.logger.Errorf("error getting song event: %v", err)
+				return
+			}
+			u.followEvent(event)
+		})
+		if time.Since(listening) > maxFeedReconnectDelay {
+			delay = time.Second
+		}
+		u.logger.Errorf("Stopped listening for song events, retrying in %v: %v", delay, err)
+		time.Sleep(delay)
+		delay = min(2*delay, maxFeedReconnectDelay)
+	}
+}
+
+// followEvent pushes an event to the subscribers and applies it to the suggestion index, once.
+func (u *UseCase) followEvent(event *repository.SongEvent) {
+	if u.feed.broadcast(event) {
+		u.suggestions.apply(event)
+	}
+}
+
+// catchUp pushes the events missed while the replica was not listening. On the first call there
+// is nothing to catch up on, it only notes where the feed stands.
+func (u *UseCase) catchUp() {
+	u.feed.mu.Lock()
+	seq := u.feed.lastSeq
+	u.feed.caughtUp = nil
+	u.feed.mu.Unlock()
+
+	if seq == 0 {
+		last, err := u.repo.GetLastSongEventSeq()
+		if err != nil {
+			u.logger.Errorf("error getting last song event: %v", err)
+			return
+		}
+		u.feed.mu.Lock()
+		u.feed.lastSeq = max(u.feed.lastSeq, last)
+		u.feed.mu.Unlock()
+		return
+	}
+
+	caughtUp := make(map[int64]bool)
+	for {
+		events, err := u.repo.GetSongEventsAfter(seq, feedPage)
+		if err != nil {
+			u.logger.Errorf("error catching up on song events: %v", err)
+			break
+		}
+		for _, event := range events {
+			u.followEvent(event)
+			seq, caughtUp[event.Seq] = event.Seq, true
+		}
+		if len(events) < feedPage {
+			break
+		}
+	}
+	u.feed.mu.Lock()
+	u.feed.caughtUp = caughtUp
+	u.feed.mu.Unlock()
+}
+
+// PurgeSongEvents trims the change feed to the configured retention.
+func (u *UseCase) PurgeSongEvents() error {
+	deleted, err := u.repo.DeleteSongEventsBefore(time.Now().Add(-u.eventRetention))
+	if err != nil {
+		return fmt.Errorf("purging song events: %v", err)
+	}
+	u.logger.Debugf("Purged %d song events", deleted)
+	return nil
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	repository "effectiveMobile/internal"
+	"fmt"
+)
+
+// BeginIdempotent claims key for a request with the given fingerprint. It returns nil when the
+// request should be processed, and the stored response when the request is a repeat that should
+// be replayed instead. A repeat of a request still in flight fails with ErrIdempotencyKeyInUse,
+// and reusing the key for another request fails with ErrIdempotencyKeyMismatch.
+func (u *UseCase) BeginIdempotent(key, fingerprint string) (*repository.IdempotentResponse, error) {
+	u.logger.Debugf("Beginning idempotent request with key: %s", key)
+	record, err := u.repo.ClaimIdempotencyKey(key, fingerprint, u.idempotencyTTL, u.idempotencyLockTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("claiming idempotency key: %v", err)
+	}
+	switch {
+	case record == nil:
+		return nil, nil
+	case record.Fingerprint != fingerprint:
+		return nil, repository.ErrIdempotencyKeyMismatch
+	case record.Response == nil:
+		return nil, repository.ErrIdempotencyKeyInUse
+	}
+
+	u.logger.Infof("Replaying response stored for idempotency key: %s", key)
+	return record.Response, nil
+}
+
+// CompleteIdempotent stores the response of the request holding key.
+func (u *UseCase) CompleteIdempotent(key string, resp *repository.IdempotentResponse) error {
+	u.logger.Debugf("Completing idempotent request with key: %s", key)
+	if err := u.repo.SaveIdempotentResponse(key, resp); err != nil {
+		return fmt.Errorf("saving idempotent response: %v", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotent frees key without storing a response, so a retry is processed again.
+func (u *UseCase) ReleaseIdempotent(key string) error {
+	u.logger.Debugf("Releasing idempotency key: %s", key)
+	if err := u.repo.ReleaseIdempotencyKey(key); err != nil {
+		return fmt.Errorf("releasing idempotency key: %v", err)
+	}
+	return nil
+}
+
+// PurgeIdempotencyKeys deletes the keys whose responses are no longer replayed.
+func (u *UseCase) PurgeIdempotencyKeys() error {
+	deleted, err := u.repo.DeleteExpiredIdempotencyKeys()
+	if err != nil {
+		return fmt.Errorf("purging idempotency keys: %v", err)
+	}
+	u.logger.Debugf("Purged %d expired idempotency keys", deleted)
+	return nil
+}
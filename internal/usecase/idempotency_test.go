@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	repository "effectiveMobile/internal"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// keyRepo returns the stored record of a key, or claims it when there is none.
+type keyRepo struct {
+	repository.Repository
+	records map[string]*repository.IdempotencyRecord
+}
+
+func (r *keyRepo) ClaimIdempotencyKey(key, fingerprint string, _, _ time.Duration) (*repository.IdempotencyRecord, error) {
+	if record, ok := r.records[key]; ok {
+		return record, nil
+	}
+	r.records[key] = &repository.IdempotencyRecord{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (r *keyRepo) SaveIdempotentResponse(key string, resp *repository.IdempotentResponse) error {
+	r.records[key].Response = resp
+	return nil
+}
+
+func (r *keyRepo) ReleaseIdempotencyKey(key string) error {
+	delete(r.records, key)
+	return nil
+}
+
+func TestBeginIdempotent(t *testing.T) {
+	repo := &keyRepo{records: map[string]*repository.IdempotencyRecord{}}
+	uc := newTestUseCase(repo)
+
+	if stored, err := uc.BeginIdempotent("k1", "f1"); stored != nil || err != nil {
+		t.Fatalf("first BeginIdempotent = %+v, %v, want the request processed", stored, err)
+	}
+	if _, err := uc.BeginIdempotent("k1", "f1"); !errors.Is(err, repository.ErrIdempotencyKeyInUse) {
+		t.Errorf("repeat while in flight error = %v, want ErrIdempotencyKeyInUse", err)
+	}
+
+	resp := &repository.IdempotentResponse{Status: 201, Header: map[string]string{"Location": "/songs/1"}, Body: []byte(`{}`)}
+	if err := uc.CompleteIdempotent("k1", resp); err != nil {
+		t.Fatalf("CompleteIdempotent error = %v", err)
+	}
+	if stored, err := uc.BeginIdempotent("k1", "f1"); err != nil || !reflect.DeepEqual(stored, resp) {
+		t.Errorf("repeat after completion = %+v, %v, want the stored response", stored, err)
+	}
+	if _, err := uc.BeginIdempotent("k1", "f2"); !errors.Is(err, repository.ErrIdempotencyKeyMismatch) {
+		t.Errorf("key reused for another request error = %v, want ErrIdempotencyKeyMismatch", err)
+	}
+
+	if _, err := uc.BeginIdempotent("k2", "f1"); err != nil {
+		t.Fatalf("BeginIdempotent(k2) error = %v", err)
+	}
+	if err := uc.ReleaseIdempotent("k2"); err != nil {
+		t.Fatalf("ReleaseIdempotent error = %v", err)
+	}
+	if stored, err := uc.BeginIdempotent("k2", "f2"); stored != nil || err != nil {
+		t.Errorf("BeginIdempotent after release = %+v, %v, want the request processed again", stored, err)
+	}
+}
@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"bufio"
+	"cmp"
+	repository "effectiveMobile/internal"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxImportLine bounds a single NDJSON line, lyrics included.
+const maxImportLine = 1 << 20
+
+// ImportSongs reads songs from a CSV or NDJSON file, validates every row and writes the valid ones.
+// Invalid rows are listed in the report without stopping the import. The report is stored so its
+// row errors can be downloaded later, for dry runs too.
+func (u *UseCase) ImportSongs(r io.Reader, opts repository.ImportOptions) (*repository.ImportReport, error) {
+	u.logger.Debugf("Importing songs from %s", opts.Format)
+	if opts.OnDuplicate == "" {
+		opts.OnDuplicate = repository.DuplicateSkip
+	}
+	if opts.OnDuplicate != repository.DuplicateSkip && opts.OnDuplicate != repository.DuplicateUpsert {
+		return nil, fmt.Errorf("%w: onDuplicate must be %s or %s", repository.ErrInvalidImport, repository.DuplicateSkip, repository.DuplicateUpsert)
+	}
+
+	report := &repository.ImportReport{
+		DryRun:      opts.DryRun,
+		Format:      opts.Format,
+		OnDuplicate: opts.OnDuplicate,
+		Errors:      []repository.ImportRowError{},
+	}
+	rows, err := readImport(r, opts, report)
+	if err != nil {
+		u.logger.Debugf("Rejected import: %v", err)
+		return nil, err
+	}
+	if opts.Enrich {
+		rows = u.enrichImport(rows, report)
+	}
+
+	if len(rows) > 0 {
+		counts, err := u.repo.ImportSongs(rows, opts.OnDuplicate, !opts.DryRun)
+		if err != nil {
+			u.logger.Errorf("error importing songs: %v", err)
+			return nil, fmt.Errorf("importing songs: %v", err)
+		}
+		report.ImportCounts = *counts
+	}
+
+	slices.SortStableFunc(report.Errors, func(a, b repository.ImportRowError) int { return a.Row - b.Row })
+	failed := make(map[int]bool)
+	for _, e := range report.Errors {
+		failed[e.Row] = true
+	}
+	report.Failed = len(failed)
+
+	if err = u.repo.SaveImportReport(report); err != nil {
+		u.logger.Errorf("error saving import report: %v", err)
+		return nil, fmt.Errorf("saving import report: %v", err)
+	}
+
+	// The rows are committed and the report saved by now, so a failed rebuild only leaves the
+	// suggestions behind until the next one; the client still gets its report.
+	if !opts.DryRun && report.Inserted+report.Updated > 0 {
+		if err = u.RebuildSuggestions(); err != nil {
+			u.logger.Warnf("Import %s finished, but suggestions were not updated: %v", report.ID, err)
+		}
+	}
+
+	u.logger.Infof("Import %s finished: %d rows, %d inserted, %d updated, %d skipped, %d failed",
+		report.ID, report.Rows, report.Inserted, report.Updated, report.Skipped, report.Failed)
+	return report, nil
+}
+
+func (u *UseCase) GetImportReport(importID string) (*repository.ImportReport, error) {
+	u.logger.Debugf("Getting import report with ID: %s", importID)
+	report, err := u.repo.GetImportReport(importID)
+	if err != nil {
+		u.logger.Errorf("error getting import report: %v", err)
+		return nil, fmt.Errorf("getting import report: %w", err)
+	}
+	return report, nil
+}
+
+// enrichImport fills the missing details of the rows from the song detail service, concurrently.
+// Rows whose details cannot be fetched are reported and left out.
+func (u *UseCase) enrichImport(rows []repository.ImportRow, report *repository.ImportReport) []repository.ImportRow {
+	failures := make([]error, len(rows))
+	parallel(len(rows), u.batchWorkers, func(i int) {
+		song := &rows[i].Song
+		if song.ReleaseDate != "" && song.Text != "" && song.Link != "" {
+			return
+		}
+		detail, err := u.FetchSongDetail(song.Group, song.Song)
+		if err != nil {
+			failures[i] = err
+			return
+		}
+		song.ReleaseDate = cmp.Or(song.ReleaseDate, detail.ReleaseDate)
+		song.Text = cmp.Or(song.Text, detail.Text)
+		song.Link = cmp.Or(song.Link, detail.Link)
+	})
+
+	enriched := rows[:0]
+	for i, row := range rows {
+		if failures[i] != nil {
+			report.Errors = append(report.Errors, repository.ImportRowError{
+				Row:   row.Row,
+				Error: fmt.Sprintf("%v: %v", repository.ErrDetailUnavailable, failures[i]),
+			})
+			continue
+		}
+		enriched = append(enriched, row)
+	}
+	return enriched
+}
+
+// readImport parses and validates the file. Row errors go to the report; a file that cannot be
+// read as a whole, such as a CSV header missing a mapped column, fails with ErrInvalidImport.
+func readImport(r io.Reader, opts repository.ImportOptions, report *repository.ImportReport) ([]repository.ImportRow, error) {
+	source := func(field string) string {
+		if column, ok := opts.Mapping[field]; ok {
+			return column
+		}
+		return field
+	}
+
+	var rows []repository.ImportRow
+	seen := make(map[[2]string]int)
+	add := func(row int, value func(field string) (string, error)) {
+		report.Rows++
+		parsed, errs := parseImportRow(row, value)
+		if len(errs) == 0 {
+			key := [2]string{parsed.Song.Group, parsed.Song.Song}
+			if first, ok := seen[key]; ok {
+				errs = append(errs, repository.ImportRowError{Row: row, Field: "song", Error: fmt.Sprintf("duplicate of row %d", first)})
+			} else {
+				seen[key] = row
+				rows = append(rows, parsed)
+			}
+		}
+		report.Errors = append(report.Errors, errs...)
+	}
+
+	switch opts.Format {
+	case "csv":
+		return rows, readImportCSV(r, source, opts.Mapping, add)
+	case "ndjson":
+		return rows, readImportNDJSON(r, source, add)
+	}
+	return nil, fmt.Errorf("%w: format must be one of %s", repository.ErrInvalidImport, strings.Join(repository.ImportFormats, ", "))
+}
+
+func readImportCSV(r io.Reader, source func(field string) string, mapping map[string]string, add func(row int, value func(field string) (string, error))) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: the file is empty", repository.ErrInvalidImport)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: reading header: %v", repository.ErrInvalidImport, err)
+	}
+
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := positions[name]; !ok {
+			positions[name] = i
+		}
+	}
+	columns := make(map[string]int, len(repository.ImportFields))
+	for _, field := range repository.ImportFields {
+		position, ok := positions[strings.ToLower(source(field))]
+		_, mapped := mapping[field]
+		if !ok && (mapped || field == "group" || field == "song") {
+			return fmt.Errorf("%w: column %q for %s not found in header", repository.ErrInvalidImport, source(field), field)
+		}
+		if ok {
+			columns[field] = position
+		}
+	}
+
+	// Rows are numbered like spreadsheet rows, the header being row 1.
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return fmt.Errorf("%w: reading row %d: %v", repository.ErrInvalidImport, row, err)
+			}
+			add(row, func(string) (string, error) { return "", parseErr.Err })
+			continue
+		}
+		add(row, func(field string) (string, error) {
+			position, ok := columns[field]
+			if !ok || position >= len(record) {
+				return "", nil
+			}
+			return record[position], nil
+		})
+	}
+}
+
+func readImportNDJSON(r io.Reader, source func(field string) string, add func(row int, value func(field string) (string, error))) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+	row := 0
+	for scanner.Scan() {
+		row++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var object map[string]any
+		if err := json.Unmarshal([]byte(line), &object); err != nil {
+			add(row, func(string) (string, error) { return "", fmt.Errorf("invalid JSON: %v", err) })
+			continue
+		}
+		add(row, func(field string) (string, error) {
+			switch value := object[source(field)].(type) {
+			case nil:
+				return "", nil
+			case string:
+				return value, nil
+			case float64, bool:
+				return fmt.Sprint(value), nil
+			default:
+				return "", fmt.Errorf("must be a string")
+			}
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: reading line %d: %v", repository.ErrInvalidImport, row+1, err)
+	}
+	return nil
+}
+
+// parseImportRow validates a row. A value function failing for every field marks a malformed row.
+func parseImportRow(row int, value func(field string) (string, error)) (repository.ImportRow, []repository.ImportRowError) {
+	var errs []repository.ImportRowError
+	values := make(map[string]string, len(repository.ImportFields))
+	for _, field := range repository.ImportFields {
+		v, err := value(field)
+		if err != nil {
+			errs = append(errs, repository.ImportRowError{Row: row, Field: field, Error: err.Error()})
+			continue
+		}
+		values[field] = strings.TrimSpace(v)
+	}
+	if len(errs) == len(repository.ImportFields) {
+		return repository.ImportRow{}, []repository.ImportRowError{{Row: row, Error: errs[0].Error}}
+	}
+
+	for _, field := range []string{"group", "song"} {
+		if v, ok := values[field]; ok && v == "" {
+			errs = append(errs, repository.ImportRowError{Row: row, Field: field, Error: "is required"})
+		}
+	}
+	if date := values["releaseDate"]; date != "" {
+		if _, err := time.Parse("02.01.2006", date); err != nil {
+			errs = append(errs, repository.ImportRowError{Row: row, Field: "releaseDate", Error: "must be a date formatted as DD.MM.YYYY"})
+		}
+	}
+	if link := values["link"]; link != "" {
+		if parsed, err := url.ParseRequestURI(link); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			errs = append(errs, repository.ImportRowError{Row: row, Field: "link", Error: "must be an http or https URL"})
+		}
+	}
+
+	result := repository.ImportRow{Row: row}
+	result.Song.Group = values["group"]
+	result.Song.Song = values["song"]
+	result.Song.ReleaseDate = values["releaseDate"]
+	result.Song.Text = values["text"]
+	result.Song.Link = values["link"]
+	return result, errs
+}
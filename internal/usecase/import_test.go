@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	repository "effectiveMobile/internal"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type importRowKey struct {
+	Row         int
+	Group, Song string
+}
+
+type importErrorKey struct {
+	Row   int
+	Field string
+}
+
+func TestReadImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		mapping string
+		input   string
+		rows    []importRowKey
+		errors  []importErrorKey
+		total   int
+	}{
+		{
+			name:   "csv",
+			format: "csv",
+			input: "\ufeffGroup, Song ,releaseDate,text,link\n" +
+				"Muse,Uprising,16.07.2006,\"Paranoia is in bloom,\nThe PR transmissions will resume\",https://www.youtube.com/watch?v=w4Xo6E_NmyU\n" +
+				"Muse,Starlight\n",
+			rows:  []importRowKey{{2, "Muse", "Uprising"}, {3, "Muse", "Starlight"}},
+			total: 2,
+		},
+		{
+			name:   "csv row errors",
+			format: "csv",
+			input: "group,song,releaseDate,link\n" +
+				"Muse,,16.07.2006,\n" +
+				"Muse,Uprising,2006-07-16,ftp://example.com\n" +
+				"Muse,Starlight,,\n" +
+				" Muse ,Starlight ,,\n" +
+				"Muse,\"Knights\"of Cydonia,,\n" +
+				"Kino,Zvezda,,\n",
+			rows:   []importRowKey{{4, "Muse", "Starlight"}, {7, "Kino", "Zvezda"}},
+			errors: []importErrorKey{{2, "song"}, {3, "releaseDate"}, {3, "link"}, {5, "song"}, {6, ""}},
+			total:  6,
+		},
+		{
+			name:    "csv mapping",
+			format:  "csv",
+			mapping: "group:Artist,song:Title,link:URL",
+			input:   "artist,title,url\nMuse,Uprising,https://muse.mu\n",
+			rows:    []importRowKey{{2, "Muse", "Uprising"}},
+			total:   1,
+		},
+		{
+			name:   "ndjson",
+			format: "ndjson",
+			input: `{"group":"Muse","song":"Uprising","releaseDate":"16.07.2006"}` + "\n" +
+				"\n" +
+				`{"group":"Muse","song":"Starlight","link":42}` + "\n" +
+				`{"group":["Muse"],"song":"Hysteria"}` + "\n" +
+				`{"group":"Muse",` + "\n" +
+				`{"group":"Muse","song":"Uprising"}` + "\n" +
+				`{"group":"Kino","song":"Zvezda","text":null}`,
+			rows:   []importRowKey{{1, "Muse", "Uprising"}, {7, "Kino", "Zvezda"}},
+			errors: []importErrorKey{{3, "link"}, {4, "group"}, {5, ""}, {6, "song"}},
+			total:  6,
+		},
+		{
+			name:    "ndjson mapping",
+			format:  "ndjson",
+			mapping: "group:artist",
+			input:   `{"artist":"Muse","song":"Uprising","group":"ignored"}`,
+			rows:    []importRowKey{{1, "Muse", "Uprising"}},
+			total:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := repository.ParseColumnMapping(tt.mapping)
+			if err != nil {
+				t.Fatalf("ParseColumnMapping(%q) error = %v", tt.mapping, err)
+			}
+			report := &repository.ImportReport{}
+			rows, err := readImport(strings.NewReader(tt.input), repository.ImportOptions{Format: tt.format, Mapping: mapping}, report)
+			if err != nil {
+				t.Fatalf("readImport error = %v", err)
+			}
+
+			gotRows := make([]importRowKey, len(rows))
+			for i, row := range rows {
+				gotRows[i] = importRowKey{row.Row, row.Song.Group, row.Song.Song}
+			}
+			var gotErrors []importErrorKey
+			for _, e := range report.Errors {
+				gotErrors = append(gotErrors, importErrorKey{e.Row, e.Field})
+			}
+			if !reflect.DeepEqual(gotRows, tt.rows) {
+				t.Errorf("rows = %v, want %v", gotRows, tt.rows)
+			}
+			if !reflect.DeepEqual(gotErrors, tt.errors) {
+				t.Errorf("errors = %+v, want %v", report.Errors, tt.errors)
+			}
+			if report.Rows != tt.total {
+				t.Errorf("report.Rows = %d, want %d", report.Rows, tt.total)
+			}
+		})
+	}
+}
+
+func TestReadImportValues(t *testing.T) {
+	input := "group,song,releaseDate,text,link\n" +
+		" Muse ,Uprising,16.07.2006,\"Paranoia is in bloom\",https://muse.mu \n"
+	rows, err := readImport(strings.NewReader(input), repository.ImportOptions{Format: "csv"}, &repository.ImportReport{})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("readImport = %v, %v, want one row", rows, err)
+	}
+	song := rows[0].Song
+	if song.Group != "Muse" || song.ReleaseDate != "16.07.2006" || song.Text != "Paranoia is in bloom" || song.Link != "https://muse.mu" {
+		t.Errorf("song = %+v, want trimmed values of the row", song)
+	}
+}
+
+func TestReadImportInvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		mapping map[string]string
+		input   string
+	}{
+		{name: "unknown format", format: "xlsx", input: "group,song\n"},
+		{name: "empty csv", format: "csv", input: ""},
+		{name: "missing required column", format: "csv", input: "group,title\nMuse,Uprising\n"},
+		{name: "missing mapped column", format: "csv", mapping: map[string]string{"link": "url"}, input: "group,song,link\n"},
+		{name: "ndjson line too long", format: "ndjson", input: `{"group":"` + strings.Repeat("a", maxImportLine) + `"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := repository.ImportOptions{Format: tt.format, Mapping: tt.mapping}
+			if _, err := readImport(strings.NewReader(tt.input), opts, &repository.ImportReport{}); !errors.Is(err, repository.ErrInvalidImport) {
+				t.Fatalf("readImport error = %v, want ErrInvalidImport", err)
+			}
+		})
+	}
+}
+
+// importRepo writes every import row and fails to list the songs for the suggestion index.
+type importRepo struct {
+	repository.Repository
+	saved *repository.ImportReport
+}
+
+func (r *importRepo) ImportSongs(rows []repository.ImportRow, _ repository.DuplicatePolicy, _ bool) (*repository.ImportCounts, error) {
+	return &repository.ImportCounts{Inserted: int64(len(rows))}, nil
+}
+
+func (r *importRepo) SaveImportReport(report *repository.ImportReport) error {
+	report.ID = "report"
+	r.saved = report
+	return nil
+}
+
+func (r *importRepo) GetSongNames() ([]repository.SongName, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestImportSongsKeepsReportWhenSuggestionsFail(t *testing.T) {
+	repo := &importRepo{}
+	report, err := newTestUseCase(repo).ImportSongs(strings.NewReader("group,song\nMuse,Uprising\n"), repository.ImportOptions{Format: "csv"})
+	if err != nil {
+		t.Fatalf("ImportSongs error = %v, want the committed import reported", err)
+	}
+	if report == nil || report.ID != "report" || report.Inserted != 1 || repo.saved != report {
+		t.Errorf("report = %+v, want the saved report of one inserted song", report)
+	}
+}
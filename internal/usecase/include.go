@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	repository "effectiveMobile/internal"
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// includeFields lists the song fields each related resource is built from.
+var includeFields = map[string][]string{
+	"artist": {"group"},
+	"links":  {"group", "song", "link"},
+}
+
+// columnsFor returns the fields to load: the selected ones plus those the includes need.
+// An empty selection loads every field.
+func columnsFor(fields, include []string) []string {
+	if len(fields) == 0 {
+		return nil
+	}
+	columns := slices.Clone(fields)
+	for _, name := range include {
+		for _, field := range includeFields[name] {
+			if !slices.Contains(columns, field) {
+				columns = append(columns, field)
+			}
+		}
+	}
+	return columns
+}
+
+// views turns loaded songs into views limited to the selected fields, with the requested resources expanded.
+func (u *UseCase) views(songs []*repository.VersionedSong, fields, include []string) ([]*repository.SongView, error) {
+	views := make([]*repository.SongView, len(songs))
+	for i, song := range songs {
+		views[i] = repository.NewSongView(song, columnsFor(fields, include))
+	}
+	if err := u.expand(views, fields, include); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+// view does the same as views for a single song.
+func (u *UseCase) view(song *repository.VersionedSong, fields, include []string) (*repository.SongView, error) {
+	views, err := u.views([]*repository.VersionedSong{song}, fields, include)
+	if err != nil {
+		return nil, err
+	}
+	return views[0], nil
+}
+
+// expandHits does the same as views for search hits, which are loaded as views already.
+func (u *UseCase) expandHits(hits []*repository.SearchHit, fields, include []string) error {
+	views := make([]*repository.SongView, len(hits))
+	for i, hit := range hits {
+		views[i] = hit.Song
+	}
+	return u.expand(views, fields, include)
+}
+
+// expand fills in the requested related resources, then drops the fields that were only loaded for them.
+func (u *UseCase) expand(views []*repository.SongView, fields, include []string) error {
+	if slices.Contains(include, "artist") {
+		if err := u.includeArtists(views); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(include, "links") {
+		for _, view := range views {
+			view.Links = songLinks(view)
+		}
+	}
+
+	if len(fields) > 0 {
+		for _, view := range views {
+			restrict(view, fields)
+		}
+	}
+	return nil
+}
+
+// GetArtists returns the named artists with their song counts, with a single query.
+func (u *UseCase) GetArtists(names []string) ([]*repository.Artist, error) {
+	u.logger.Debugf("Getting %d artists", len(names))
+	artists, err := u.repo.GetArtists(names)
+	if err != nil {
+		u.logger.Errorf("error getting artists: %v", err)
+		return nil, fmt.Errorf("getting artists: %v", err)
+	}
+	return artists, nil
+}
+
+// includeArtists loads the artists of all songs with a single query.
+func (u *UseCase) includeArtists(views []*repository.SongView) error {
+	var names []string
+	for _, view := range views {
+		if group := value(view.Group); group != "" && !slices.Contains(names, group) {
+			names = append(names, group)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	artists, err := u.GetArtists(names)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]*repository.Artist, len(artists))
+	for _, artist := range artists {
+		byName[artist.Name] = artist
+	}
+	for _, view := range views {
+		view.Artist = byName[value(view.Group)]
+	}
+	return nil
+}
+
+func songLinks(view *repository.SongView) *repository.SongLinks {
+	return &repository.SongLinks{
+		Self:  "/songs/" + url.PathEscape(view.Id),
+		Slug:  view.Slug.Path(),
+		Text:  "/songs/text?" + url.Values{"group": {value(view.Group)}, "song": {value(view.Song)}}.Encode(),
+		Video: value(view.Link),
+	}
+}
+
+// restrict clears the fields of view that were not selected.
+func restrict(view *repository.SongView, fields []string) {
+	if !slices.Contains(fields, "group") {
+		view.Group = nil
+	}
+	if !slices.Contains(fields, "song") {
+		view.Song = nil
+	}
+	if !slices.Contains(fields, "releaseDate") {
+		view.ReleaseDate = nil
+	}
+	if !slices.Contains(fields, "text") {
+		view.Text = nil
+	}
+	if !slices.Contains(fields, "link") {
+		view.Link = nil
+	}
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	repository "effectiveMobile/internal"
+	"effectiveMobile/pkg/publisher"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+)
+
+const (
+	// outboxPollInterval is how often the outbox is looked at once it has been emptied.
+	outboxPollInterval = 500 * time.Millisecond
+	// outboxBatch is how many events are relayed per transaction, and outboxWorkers how many
+	// songs have their events published concurrently.
+	outboxBatch   = 100
+	outboxWorkers = 8
+	// maxOutboxBackoff bounds the wait before retrying a song whose events failed to publish, or
+	// the relay after the database failed.
+	maxOutboxBackoff = time.Minute
+)
+
+// relayStats are the figures of the relay running on this replica.
+type relayStats struct {
+	mu              sync.Mutex
+	relaying        bool
+	published       int64
+	failed          int64
+	lastPublishedAt *time.Time
+	lastLatency     time.Duration
+}
+
+// outboxRetries are the songs whose events failed to publish, each waiting out its own backoff
+// before its events are read again. Only the relay goroutine uses them.
+type outboxRetries map[string]*outboxRetry
+
+type outboxRetry struct {
+	at      time.Time
+	backoff time.Duration
+}
+
+// waiting returns the songs still backing off at now.
+func (r outboxRetries) waiting(now time.Time) []string {
+	var songIDs []string
+	for songID, retry := range r {
+		if now.Before(retry.at) {
+			songIDs = append(songIDs, songID)
+		}
+	}
+	return songIDs
+}
+
+// fail schedules the next attempt of a song, doubling its backoff after every failure in a row.
+func (r outboxRetries) fail(songID string, now time.Time) {
+	retry, ok := r[songID]
+	if !ok {
+		retry = &outboxRetry{backoff: outboxPollInterval}
+		r[songID] = retry
+	} else {
+		retry.backoff = min(2*retry.backoff, maxOutboxBackoff)
+	}
+	retry.at = now.Add(retry.backoff)
+}
+
+// saveEvent records a song event in the outbox. repo must be the transaction making the change,
+// so the event is committed with it or not at all.
+func (u *UseCase) saveEvent(repo repository.Repository, eventType, songID, group string, version int64, song *openapi.Song) error {
+	return repo.SaveOutboxEvent(repository.NewSongEvent(eventType, songID, group, version, song))
+}
+
+// RunOutboxRelay publishes the outbox events until the process exits. Each event is published
+// at least once: it is deleted from the outbox only after the publisher accepted it, so a
+// failure or crash in between publishes it again. The events of a song are published in order;
+// one that fails holds back the following events of its song, which backs off on its own and is
+// left out of the reads meanwhile, so other songs are neither delayed nor crowded out. Once
+// published, events are added to the change feed and scheduled for the webhooks in the
+// transaction deleting them from the outbox. A single replica relays at a time.
+func (u *UseCase) RunOutboxRelay(pub publisher.Publisher) {
+	retries := outboxRetries{}
+	backoff := outboxPollInterval
+	for {
+		relayed, err := u.relayOutbox(pub, retries)
+		if err != nil {
+			u.logger.Errorf("error relaying outbox: %v", err)
+			time.Sleep(backoff)
+			backoff = min(2*backoff, maxOutboxBackoff)
+			continue
+		}
+		backoff = outboxPollInterval
+		if relayed < outboxBatch {
+			time.Sleep(outboxPollInterval)
+		}
+	}
+}
+
+// relayOutbox relays one batch of events, skipping the songs still backing off, and schedules
+// the songs that failed to publish. It returns how many events were read.
+func (u *UseCase) relayOutbox(pub publisher.Publisher, retries outboxRetries) (int, error) {
+	var read int
+	err := u.repo.InTx(func(repo repository.Repository) error {
+		locked, err := repo.LockOutbox()
+		u.relay.mu.Lock()
+		u.relay.relaying = locked
+		u.relay.mu.Unlock()
+		if err != nil || !locked {
+			return err
+		}
+
+		events, err := repo.GetOutboxEvents(outboxBatch, retries.waiting(time.Now()))
+		if err != nil {
+			return err
+		}
+		read = len(events)
+		published := u.publishEvents(pub, events)
+
+		now := time.Now()
+		failed := make(map[string]bool)
+		for _, event := range events {
+			if !slices.Contains(published, event) && !failed[event.SongID] {
+				failed[event.SongID] = true
+				retries.fail(event.SongID, now)
+			}
+		}
+		for _, event := range published {
+			if !failed[event.SongID] {
+				delete(retries, event.SongID)
+			}
+		}
+
+		var ids []string
+		for _, event := range published {
+			if err = repo.SaveSongEvent(event); err != nil {
+				return err
+			}
+			if err = u.enqueueDeliveries(repo, event); err != nil {
+				return err
+			}
+			ids = append(ids, event.ID)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return repo.DeleteOutboxEvents(ids)
+	})
+	return read, err
+}
+
+// publishEvents publishes the events, the songs concurrently and the events of each song in
+// order, stopping at the first failure of a song. It returns the published events in their
+// original order.
+func (u *UseCase) publishEvents(pub publisher.Publisher, events []*repository.SongEvent) []*repository.SongEvent {
+	var songs []string
+	bySong := make(map[string][]int)
+	for i, event := range events {
+		if _, ok := bySong[event.SongID]; !ok {
+			songs = append(songs, event.SongID)
+		}
+		bySong[event.SongID] = append(bySong[event.SongID], i)
+	}
+
+	ok := make([]bool, len(events))
+	parallel(len(songs), outboxWorkers, func(s int) {
+		for _, i := range bySong[songs[s]] {
+			if err := u.publishEvent(pub, events[i]); err != nil {
+				u.logger.Errorf("error publishing %s event %s: %v", events[i].Type, events[i].ID, err)
+				return
+			}
+			ok[i] = true
+		}
+	})
+
+	var published []*repository.SongEvent
+	for i, event := range events {
+		if ok[i] {
+			published = append(published, event)
+		}
+	}
+	return published
+}
+
+func (u *UseCase) publishEvent(pub publisher.Publisher, event *repository.SongEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding event: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), u.outboxTimeout)
+	defer cancel()
+
+	err = pub.Publish(ctx, publisher.Message{
+		ID:      event.ID,
+		Subject: u.outboxSubject + "." + event.Type,
+		Key:     event.SongID,
+		Body:    body,
+	})
+
+	u.relay.mu.Lock()
+	defer u.relay.mu.Unlock()
+	if err != nil {
+		u.relay.failed++
+		return err
+	}
+	now := time.Now().UTC()
+	u.relay.published++
+	u.relay.lastPublishedAt = &now
+	u.relay.lastLatency = now.Sub(event.OccurredAt)
+	return nil
+}
+
+// GetOutboxStats reports the backlog of the outbox and how the relay of this replica is doing.
+func (u *UseCase) GetOutboxStats() (*repository.OutboxStats, error) {
+	stats, err := u.repo.GetOutboxStats()
+	if err != nil {
+		return nil, fmt.Errorf("getting outbox stats: %v", err)
+	}
+	if stats.OldestPending != nil {
+		stats.LagSeconds = max(time.Since(*stats.OldestPending).Seconds(), 0)
+	}
+
+	u.relay.mu.Lock()
+	defer u.relay.mu.Unlock()
+	stats.Relaying = u.relay.relaying
+	stats.Published, stats.Failed = u.relay.published, u.relay.failed
+	stats.LastPublishedAt = u.relay.lastPublishedAt
+	stats.LastLatencySeconds = u.relay.lastLatency.Seconds()
+	return stats, nil
+}
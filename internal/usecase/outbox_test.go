@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	repository "effectiveMobile/internal"
+	"effectiveMobile/pkg/publisher"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// outboxRepo keeps the outbox in memory, in the order events were saved. Methods the tests do
+// not need panic through the nil embedded Repository.
+type outboxRepo struct {
+	repository.Repository
+	outbox  []*repository.SongEvent
+	relayed []string
+}
+
+func (r *outboxRepo) InTx(fn func(repo repository.Repository) error) error {
+	return fn(r)
+}
+
+func (r *outboxRepo) LockOutbox() (bool, error) {
+	return true, nil
+}
+
+func (r *outboxRepo) GetOutboxEvents(limit int, skipSongs []string) ([]*repository.SongEvent, error) {
+	var events []*repository.SongEvent
+	for _, event := range r.outbox {
+		if len(events) < limit && !slices.Contains(skipSongs, event.SongID) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *outboxRepo) SaveSongEvent(event *repository.SongEvent) error {
+	r.relayed = append(r.relayed, event.SongID)
+	return nil
+}
+
+func (r *outboxRepo) EnqueueWebhookDeliveries(string, []byte) (int64, error) {
+	return 0, nil
+}
+
+func (r *outboxRepo) DeleteOutboxEvents(eventIDs []string) error {
+	r.outbox = slices.DeleteFunc(r.outbox, func(event *repository.SongEvent) bool {
+		return slices.Contains(eventIDs, event.ID)
+	})
+	return nil
+}
+
+// songPublisher rejects the events of the failing songs.
+type songPublisher struct {
+	mu      sync.Mutex
+	failing map[string]bool
+}
+
+func (p *songPublisher) Publish(_ context.Context, msg publisher.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failing[msg.Key] {
+		return errors.New("sink unavailable")
+	}
+	return nil
+}
+
+func (p *songPublisher) Close() error {
+	return nil
+}
+
+func TestRelayOutboxSkipsFailingSongs(t *testing.T) {
+	repo := &outboxRepo{}
+	// A full batch of events of the failing song sits ahead of the other songs.
+	for range outboxBatch {
+		repo.outbox = append(repo.outbox, repository.NewSongEvent(repository.EventSongUpdated, "stuck", "Muse", 2, nil))
+	}
+	repo.outbox = append(repo.outbox,
+		repository.NewSongEvent(repository.EventSongUpdated, "a", "Muse", 2, nil),
+		repository.NewSongEvent(repository.EventSongDeleted, "b", "Kino", 0, nil))
+	uc := newTestUseCase(repo)
+	uc.relay = &relayStats{}
+	uc.outboxTimeout = time.Second
+	pub := &songPublisher{failing: map[string]bool{"stuck": true}}
+	retries := outboxRetries{}
+
+	if _, err := uc.relayOutbox(pub, retries); err != nil {
+		t.Fatalf("relayOutbox error = %v", err)
+	}
+	if _, ok := retries["stuck"]; !ok || len(repo.relayed) != 0 {
+		t.Fatalf("after the first pass retries = %v and relayed %v, want the failing song backing off", retries, repo.relayed)
+	}
+
+	if _, err := uc.relayOutbox(pub, retries); err != nil {
+		t.Fatalf("relayOutbox error = %v", err)
+	}
+	if !slices.Equal(repo.relayed, []string{"a", "b"}) {
+		t.Errorf("relayed %v while a song backs off, want the events of the other songs", repo.relayed)
+	}
+	if len(repo.outbox) != outboxBatch {
+		t.Errorf("%d events left in the outbox, want the %d of the failing song", len(repo.outbox), outboxBatch)
+	}
+
+	// Once its backoff is over, the song is read again and relayed when the publisher recovers.
+	retries["stuck"].at = time.Now()
+	pub.failing = nil
+	if _, err := uc.relayOutbox(pub, retries); err != nil {
+		t.Fatalf("relayOutbox error = %v", err)
+	}
+	if len(repo.outbox) != 0 || len(retries) != 0 {
+		t.Errorf("%d events left and retries %v after recovery, want none", len(repo.outbox), retries)
+	}
+}
+
+func TestOutboxRetriesBackoff(t *testing.T) {
+	retries := outboxRetries{}
+	now := time.Now()
+	want := outboxPollInterval
+	for range 10 {
+		retries.fail("a", now)
+		if got := retries["a"].backoff; got != want {
+			t.Fatalf("backoff = %v, want %v", got, want)
+		}
+		want = min(2*want, maxOutboxBackoff)
+	}
+	retries.fail("b", now)
+
+	if got := retries.waiting(now); len(got) != 2 {
+		t.Errorf("waiting now = %v, want both songs", got)
+	}
+	if got := retries.waiting(now.Add(outboxPollInterval)); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("waiting after the first backoff = %v, want only the song failing for longer", got)
+	}
+	if got := retries.waiting(now.Add(maxOutboxBackoff)); len(got) != 0 {
+		t.Errorf("waiting after the longest backoff = %v, want none", got)
+	}
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	repository "effectiveMobile/internal"
+)
+
+const (
+	defaultPageLimit = 10
+	maxPageLimit     = 100
+)
+
+// pageLimit clamps the requested page size. Callers fetch one extra row
+// to tell whether another page follows in the requested direction.
+func pageLimit(requested *int32) int32 {
+	if requested == nil {
+		return defaultPageLimit
+	}
+	return min(*requested, maxPageLimit)
+}
+
+// trimPage drops the look-ahead row and returns the tokens of the neighbouring pages.
+// skipped reports whether rows before this page were skipped by an offset.
+func trimPage[T any](items []T, cursors []repository.Cursor, limit int32, cursor *repository.Cursor, skipped bool) ([]T, *string, *string) {
+	backward := cursor != nil && cursor.Backward
+	hasMore := len(items) > int(limit)
+	if hasMore {
+		if backward {
+			items, cursors = items[len(items)-int(limit):], cursors[len(cursors)-int(limit):]
+		} else {
+			items, cursors = items[:limit], cursors[:limit]
+		}
+	}
+	if len(items) == 0 {
+		return []T{}, nil, nil
+	}
+
+	hasNext, hasPrev := hasMore, cursor != nil || skipped
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	var next, prev *string
+	if hasNext {
+		token := cursors[len(cursors)-1].Encode()
+		next = &token
+	}
+	if hasPrev {
+		first := cursors[0]
+		first.Backward = true
+		token := first.Encode()
+		prev = &token
+	}
+	return items, next, prev
+}
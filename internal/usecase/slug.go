@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	repository "effectiveMobile/internal"
+	"errors"
+	"fmt"
+)
+
+// GetSongBySlug returns the song addressed by the slugs, limited to the selected fields with the
+// requested resources expanded. Former slugs of a renamed song still find it; the slug of the
+// returned view is the current one, so callers can tell and redirect.
+func (u *UseCase) GetSongBySlug(artistSlug, slug string, fields, include []string) (*repository.SongView, error) {
+	u.logger.Debugf("Getting song with slug: %s/%s", artistSlug, slug)
+	song, err := u.repo.GetSongBySlug(artistSlug, slug, columnsFor(fields, include))
+	if err != nil {
+		if !errors.Is(err, repository.ErrSongNotFound) {
+			u.logger.Errorf("error getting song by slug: %v", err)
+		}
+		return nil, fmt.Errorf("getting song by slug: %w", err)
+	}
+	return u.view(song, fields, include)
+}
+
+// AssignMissingSlugs gives slugs to the songs written before slugs existed.
+func (u *UseCase) AssignMissingSlugs() error {
+	u.logger.Debug("Assigning missing song slugs")
+	assigned, err := u.repo.AssignMissingSlugs()
+	if err != nil {
+		u.logger.Errorf("error assigning song slugs: %v", err)
+		return fmt.Errorf("assigning song slugs: %v", err)
+	}
+
+	u.logger.Infof("Successfully assigned slugs to %d songs", assigned)
+	return nil
+}
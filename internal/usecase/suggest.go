@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	repository "effectiveMobile/internal"
+	"effectiveMobile/pkg/suggest"
+	"sync"
+)
+
+// suggestions keeps the autocomplete index in line with the songs table.
+// Artists are weighted by their number of songs.
+type suggestions struct {
+	mu      sync.Mutex
+	index   *suggest.Index[repository.Suggestion]
+	artists map[string]string
+	counts  map[string]int
+}
+
+func newSuggestions() *suggestions {
+	return &suggestions{
+		index:   suggest.NewIndex[repository.Suggestion](),
+		artists: make(map[string]string),
+		counts:  make(map[string]int),
+	}
+}
+
+func artistKey(group string) string {
+	return "artist:" + suggest.Normalize(group)
+}
+
+func songItem(name repository.SongName) suggest.Item[repository.Suggestion] {
+	return suggest.Item[repository.Suggestion]{
+		ID:     name.ID,
+		Text:   name.Song,
+		Weight: 1,
+		Value:  repository.Suggestion{Type: repository.SuggestionSong, Text: name.Song, Group: name.Group, ID: name.ID},
+	}
+}
+
+func artistItem(key, group string, count int) suggest.Item[repository.Suggestion] {
+	return suggest.Item[repository.Suggestion]{
+		ID:     key,
+		Text:   group,
+		Weight: count,
+		Value:  repository.Suggestion{Type: repository.SuggestionArtist, Text: group},
+	}
+}
+
+// reset rebuilds the index from scratch.
+func (s *suggestions) reset(names []repository.SongName) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.artists = make(map[string]string, len(names))
+	s.counts = make(map[string]int)
+	groups := make(map[string]string)
+	items := make([]suggest.Item[repository.Suggestion], 0, len(names))
+	for _, name := range names {
+		items = append(items, songItem(name))
+		if name.Group == "" {
+			continue
+		}
+		key := artistKey(name.Group)
+		s.artists[name.ID] = key
+		s.counts[key]++
+		if _, ok := groups[key]; !ok {
+			groups[key] = name.Group
+		}
+	}
+	for key, group := range groups {
+		items = append(items, artistItem(key, group, s.counts[key]))
+	}
+	s.index.Reset(items)
+}
+
+// put indexes a created or updated song.
+func (s *suggestions) put(name repository.SongName) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeArtist(name.ID)
+	s.index.Put(songItem(name))
+	if name.Group == "" {
+		return
+	}
+
+	key := artistKey(name.Group)
+	s.artists[name.ID] = key
+	s.counts[key]++
+	group := name.Group
+	if existing, ok := s.index.Get(key); ok {
+		group = existing.Text
+	}
+	s.index.Put(artistItem(key, group, s.counts[key]))
+}
+
+// remove drops a deleted song.
+func (s *suggestions) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeArtist(id)
+	s.index.Remove(id)
+}
+
+// removeArtist releases the song's share of its artist's weight, dropping artists without songs.
+func (s *suggestions) removeArtist(id string) {
+	key, ok := s.artists[id]
+	if !ok {
+		return
+	}
+	delete(s.artists, id)
+	s.counts[key]--
+	if s.counts[key] > 0 {
+		if existing, ok := s.index.Get(key); ok {
+			s.index.Put(artistItem(key, existing.Text, s.counts[key]))
+		}
+		return
+	}
+	delete(s.counts, key)
+	s.index.Remove(key)
+}
+
+// apply follows a song event, which may come from any replica. Events of a song arrive in the
+// order of its changes, so the index ends up as the last of them left the song.
+func (s *suggestions) apply(event *repository.SongEvent) {
+	switch {
+	case event.Type == repository.EventSongDeleted:
+		s.remove(event.SongID)
+	case event.Song != nil:
+		s.put(repository.SongName{ID: event.SongID, Group: event.Song.Group, Song: event.Song.Song})
+	}
+}
+
+func (s *suggestions) search(query string, limit int) []repository.Suggestion {
+	return s.index.Search(query, limit)
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	repository "effectiveMobile/internal"
+	"testing"
+
+	openapi "github.com/Lineblaze/effective_mobile_gen"
+)
+
+func newTestSuggestions() *suggestions {
+	s := newSuggestions()
+	s.reset([]repository.SongName{
+		{ID: "1", Group: "Muse", Song: "Uprising"},
+		{ID: "2", Group: "Muse", Song: "Starlight"},
+		{ID: "3", Group: "Кино", Song: "Группа крови"},
+		{ID: "4", Song: "Untitled"},
+	})
+	return s
+}
+
+func artistWeight(t *testing.T, s *suggestions, group string) int {
+	t.Helper()
+	item, ok := s.index.Get(artistKey(group))
+	if !ok {
+		return 0
+	}
+	return item.Weight
+}
+
+func TestSuggestionsReset(t *testing.T) {
+	s := newTestSuggestions()
+	if got := artistWeight(t, s, "Muse"); got != 2 {
+		t.Errorf("Muse weight = %d, want 2", got)
+	}
+	if got := artistWeight(t, s, "kino"); got != 1 {
+		t.Errorf("Кино weight = %d, want 1", got)
+	}
+
+	got := s.search("u", 10)
+	want := []repository.Suggestion{
+		{Type: repository.SuggestionSong, Text: "Untitled", ID: "4"},
+		{Type: repository.SuggestionSong, Text: "Uprising", Group: "Muse", ID: "1"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("search(u) = %+v, want %+v", got, want)
+	}
+}
+
+func TestSuggestionsRename(t *testing.T) {
+	s := newTestSuggestions()
+	s.search("st", 10)
+
+	s.put(repository.SongName{ID: "2", Group: "Kino", Song: "Zvezda"})
+	if got := s.search("st", 10); len(got) != 0 {
+		t.Errorf("search(st) after rename = %+v, want none", got)
+	}
+	if got := s.search("zv", 10); len(got) != 1 || got[0].Group != "Kino" {
+		t.Errorf("search(zv) after rename = %+v, want the renamed song", got)
+	}
+	if got := artistWeight(t, s, "Muse"); got != 1 {
+		t.Errorf("Muse weight after moving a song away = %d, want 1", got)
+	}
+	// Кино and Kino normalize alike, so they are one artist keeping the first spelling.
+	item, _ := s.index.Get(artistKey("Kino"))
+	if item.Weight != 2 || item.Text != "Кино" {
+		t.Errorf("Кино after moving a song in = %+v, want weight 2 under its first spelling", item)
+	}
+	if song, _ := s.index.Get("2"); song.Value.Group != "Kino" {
+		t.Errorf("song 2 after rename = %+v, want it under Kino", song)
+	}
+}
+
+func TestSuggestionsRemove(t *testing.T) {
+	s := newTestSuggestions()
+	s.remove("1")
+	if got := artistWeight(t, s, "Muse"); got != 1 {
+		t.Errorf("Muse weight after one delete = %d, want 1", got)
+	}
+
+	s.remove("2")
+	if _, ok := s.index.Get(artistKey("Muse")); ok {
+		t.Error("Muse is still suggested without songs")
+	}
+	if got := s.search("mu", 10); len(got) != 0 {
+		t.Errorf("search(mu) after deleting every Muse song = %+v, want none", got)
+	}
+	if _, ok := s.index.Get("1"); ok {
+		t.Error("song 1 is still suggested after delete")
+	}
+
+	s.remove("unknown")
+	if got := artistWeight(t, s, "kino"); got != 1 {
+		t.Errorf("Кино weight after deleting an unknown song = %d, want 1", got)
+	}
+}
+
+func TestSuggestionsApply(t *testing.T) {
+	s := newTestSuggestions()
+	s.apply(repository.NewSongEvent(repository.EventSongCreated, "5", "Kino", 1, &openapi.Song{Id: "5", Group: "Kino", Song: "Zvezda"}))
+	s.apply(repository.NewSongEvent(repository.EventSongUpdated, "1", "Muse", 2, &openapi.Song{Id: "1", Group: "Muse", Song: "Hysteria"}))
+	s.apply(repository.NewSongEvent(repository.EventSongDeleted, "2", "Muse", 0, nil))
+
+	if got := s.search("zv", 10); len(got) != 1 || got[0].ID != "5" {
+		t.Errorf("search(zv) after a created event = %+v, want the new song", got)
+	}
+	if got := s.search("upr", 10); len(got) != 0 {
+		t.Errorf("search(upr) after a renaming event = %+v, want none", got)
+	}
+	if got := s.search("st", 10); len(got) != 0 {
+		t.Errorf("search(st) after a deleted event = %+v, want none", got)
+	}
+	if got := artistWeight(t, s, "Muse"); got != 1 {
+		t.Errorf("Muse weight = %d, want 1", got)
+	}
+}
+
+func TestFollowEventAppliesOnce(t *testing.T) {
+	uc := newTestUseCase(newMemRepo())
+	uc.feed = newFeed()
+	uc.suggestions = newTestSuggestions()
+
+	updated := repository.NewSongEvent(repository.EventSongUpdated, "1", "Muse", 2, &openapi.Song{Id: "1", Group: "Muse", Song: "Hysteria"})
+	deleted := repository.NewSongEvent(repository.EventSongDeleted, "1", "Muse", 0, nil)
+	updated.Seq, deleted.Seq = 7, 8
+	// Both were pushed while catching up, and the notification of the update arrives late.
+	uc.followEvent(updated)
+	uc.followEvent(deleted)
+	uc.feed.caughtUp = map[int64]bool{7: true, 8: true}
+	uc.followEvent(updated)
+
+	if got := uc.suggestions.search("hy", 10); len(got) != 0 {
+		t.Errorf("search(hy) = %+v, want the deleted song not brought back by a repeated event", got)
+	}
+}
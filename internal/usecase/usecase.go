@@ -1,30 +1,100 @@
 package usecase
 
 import (
+	"context"
+	"effectiveMobile/config"
 	repository "effectiveMobile/internal"
 	"effectiveMobile/pkg/logger"
+	"effectiveMobile/pkg/requestid"
 	"encoding/json"
+	"errors"
 	"fmt"
 	openapi "github.com/Lineblaze/effective_mobile_gen"
+	"github.com/google/uuid"
 	"net/http"
-	"regexp"
-	"strings"
+	"time"
+	"unicode"
 )
 
+const maxSuggestions = 3
+
 //go:generate ifacemaker -f *.go -o ../usecase.go -i UseCase -s UseCase -p internal -y "Controller describes methods, implemented by the usecase package."
 type UseCase struct {
-	repo   repository.Repository
-	logger *logger.ApiLogger
+	repo          repository.Repository
+	logger        *logger.ApiLogger
+	suggestions   *suggestions
+	batchMaxItems int
+	batchWorkers  int
+
+	idempotencyTTL         time.Duration
+	idempotencyLockTimeout time.Duration
+
+	webhookClient      *http.Client
+	webhookMaxAttempts int
+	webhookBackoff     time.Duration
+
+	feed           *feed
+	eventRetention time.Duration
+
+	relay         *relayStats
+	outboxSubject string
+	outboxTimeout time.Duration
+
+	textDefaultLimit int
+
+	ctx context.Context
 }
 
-func NewUseCase(repo repository.Repository, logger *logger.ApiLogger) *UseCase {
-	return &UseCase{repo: repo, logger: logger}
+func NewUseCase(repo repository.Repository, cfg *config.Config, logger *logger.ApiLogger) *UseCase {
+	return &UseCase{
+		repo:          repo,
+		logger:        logger,
+		suggestions:   newSuggestions(),
+		batchMaxItems: cfg.Batch.MaxItems,
+		batchWorkers:  cfg.Batch.Workers,
+
+		idempotencyTTL:         cfg.Idempotency.TTL,
+		idempotencyLockTimeout: cfg.Idempotency.LockTimeout,
+
+		webhookClient:      &http.Client{Timeout: cfg.Webhooks.Timeout},
+		webhookMaxAttempts: cfg.Webhooks.MaxAttempts,
+		webhookBackoff:     cfg.Webhooks.Backoff,
+
+		feed:           newFeed(),
+		eventRetention: cfg.Events.Retention,
+
+		relay:         &relayStats{},
+		outboxSubject: cfg.Outbox.Subject,
+		outboxTimeout: cfg.Outbox.Timeout,
+
+		textDefaultLimit: cfg.Text.DefaultLimit,
+
+		ctx: context.Background(),
+	}
+}
+
+// WithContext returns the use case serving the request of ctx: everything it does, down to the
+// repository, is logged with the request ID, which FetchSongDetail also passes on.
+func (u *UseCase) WithContext(ctx context.Context) repository.UseCase {
+	bound := *u
+	bound.repo = u.repo.WithContext(ctx)
+	bound.logger = u.logger.WithContext(ctx)
+	bound.ctx = ctx
+	return &bound
 }
 
 func (u *UseCase) FetchSongDetail(group, song string) (*openapi.SongDetail, error) {
 	u.logger.Debugf("Fetching song detail for group: %s, song: %s", group, song)
 	apiURL := fmt.Sprintf("http://localhost:8080/info?group=%s&song=%s", group, song)
-	resp, err := http.Get(apiURL)
+	req, err := http.NewRequestWithContext(u.ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		u.logger.Errorf("failed to build song detail request: %v", err)
+		return nil, fmt.Errorf("failed to fetch song detail: %v", err)
+	}
+	if id := requestid.FromContext(u.ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		u.logger.Errorf("failed to fetch song detail: %v", err)
 		return nil, fmt.Errorf("failed to fetch song detail: %v", err)
@@ -53,64 +123,157 @@ func (u *UseCase) GetSongDetail(group, song string) (*openapi.SongDetail, error)
 	return songDetail, nil
 }
 
-func (u *UseCase) GetSongs(body *openapi.GetSongsBody) ([]*openapi.Song, error) {
+// GetSongDetails returns the stored details of several songs at once. Songs without details are left out.
+func (u *UseCase) GetSongDetails(keys []repository.SongKey) (map[repository.SongKey]*openapi.SongDetail, error) {
+	u.logger.Debugf("Getting %d song details", len(keys))
+	details, err := u.repo.GetSongDetails(keys)
+	if err != nil {
+		u.logger.Errorf("error getting song details: %v", err)
+		return nil, fmt.Errorf("getting song details: %v", err)
+	}
+	return details, nil
+}
+
+func (u *UseCase) GetSongs(q *repository.SongsQuery) (*repository.SongsPage, error) {
 	u.logger.Debug("Getting songs with filter parameters")
-	songs, err := u.repo.GetSongs(body)
+
+	query := *q
+	query.Fields = columnsFor(q.Fields, q.Include)
+	var limit int32
+	if !q.Unpaged {
+		limit = pageLimit(q.Filter.Limit)
+		fetch := limit + 1
+		query.Filter.Limit = &fetch
+	}
+
+	songs, cursors, err := u.repo.GetSongs(&query)
 	if err != nil {
 		u.logger.Errorf("error getting songs: %v", err)
 		return nil, fmt.Errorf("getting songs: %v", err)
 	}
 
-	u.logger.Infof("Successfully retrieved %d songs", len(songs))
-	return songs, nil
+	page := &repository.SongsPage{}
+	if !q.Unpaged {
+		skipped := q.Filter.Offset != nil && *q.Filter.Offset > 0
+		songs, page.NextCursor, page.PrevCursor = trimPage(songs, cursors, limit, q.Cursor, skipped)
+	}
+	if page.Items, err = u.views(songs, q.Fields, q.Include); err != nil {
+		return nil, err
+	}
+
+	if q.WithTotal {
+		total, err := u.repo.CountSongs(q)
+		if err != nil {
+			u.logger.Errorf("error counting songs: %v", err)
+			return nil, fmt.Errorf("counting songs: %v", err)
+		}
+		page.Total = &total
+	}
+
+	if len(page.Items) == 0 && q.Cursor == nil && (q.Filter.Group != nil || q.Filter.Song != nil) {
+		if page.DidYouMean, err = u.didYouMean(q.Filter.Group, q.Filter.Song); err != nil {
+			return nil, err
+		}
+	}
+
+	u.logger.Infof("Successfully retrieved %d songs", len(page.Items))
+	return page, nil
 }
 
-func (u *UseCase) GetSongText(body *openapi.GetSongTextBody) ([][]string, error) {
-	u.logger.Debugf("Getting song text for group: %s, song: %s", body.Group, body.Song)
-	songText, err := u.repo.GetSongText(body.Group, body.Song)
+// GetSong returns the song limited to the selected fields, with the requested resources expanded.
+func (u *UseCase) GetSong(songID string, fields, include []string) (*repository.SongView, error) {
+	u.logger.Debugf("Getting song with ID: %s", songID)
+	if uuid.Validate(songID) != nil {
+		return nil, fmt.Errorf("getting song: %w", repository.ErrSongNotFound)
+	}
+
+	song, err := u.repo.GetSong(songID, columnsFor(fields, include))
 	if err != nil {
-		u.logger.Errorf("error getting song text: %v", err)
-		return nil, fmt.Errorf("getting song text: %v", err)
-	}
-
-	re := regexp.MustCompile("\\\\n\\\\n")
-	split := re.Split(songText, -1)
-	var verses [][]string
-
-	for _, verse := range split {
-		trimmedVerse := strings.TrimSpace(verse)
-		if trimmedVerse != "" {
-			lines := strings.Split(trimmedVerse, "\n")
-			for i, line := range lines {
-				lines[i] = strings.TrimSuffix(line, "\\n")
-			}
-			verses = append(verses, lines)
+		if !errors.Is(err, repository.ErrSongNotFound) {
+			u.logger.Errorf("error getting song: %v", err)
 		}
+		return nil, fmt.Errorf("getting song: %w", err)
 	}
+	return u.view(song, fields, include)
+}
 
-	start := 0
-	if body.Offset != nil {
-		start = int(*body.Offset)
+// ExportSongs streams every song matching the filters to fn, limited to the selected fields.
+func (u *UseCase) ExportSongs(q *repository.SongsQuery, fn func(song *repository.SongView) error) error {
+	u.logger.Debug("Exporting songs with filter parameters")
+	err := u.repo.ExportSongs(q, func(song *repository.VersionedSong) error {
+		return fn(repository.NewSongView(song, q.Fields))
+	})
+	if err != nil {
+		u.logger.Errorf("error exporting songs: %v", err)
+		return fmt.Errorf("exporting songs: %w", err)
 	}
-	if start > len(verses) {
-		start = len(verses)
+	return nil
+}
+
+// GetSongText returns the verses picked by the query, or a page of all verses without a selection.
+// Pages are TEXT_DEFAULT_LIMIT verses long unless the query sets a limit.
+func (u *UseCase) GetSongText(q *repository.TextQuery) (*repository.SongText, error) {
+	var (
+		songText string
+		err      error
+	)
+	if q.SongID != "" {
+		u.logger.Debugf("Getting song text for song with ID: %s", q.SongID)
+		if uuid.Validate(q.SongID) != nil {
+			return nil, fmt.Errorf("getting song text: %w", repository.ErrSongNotFound)
+		}
+		songText, err = u.repo.GetSongTextByID(q.SongID)
+	} else {
+		u.logger.Debugf("Getting song text for group: %s, song: %s", q.Group, q.Song)
+		songText, err = u.repo.GetSongText(q.Group, q.Song)
+	}
+	if err != nil {
+		if !errors.Is(err, repository.ErrSongNotFound) {
+			u.logger.Errorf("error getting song text: %v", err)
+		}
+		return nil, fmt.Errorf("getting song text: %w", err)
 	}
 
-	limit := 5
-	if body.Limit != nil {
-		limit = int(*body.Limit)
+	verses := repository.NumberVerses(songText)
+	text := &repository.SongText{
+		Verses:      [][]string{},
+		Numbers:     []int{},
+		FirstLines:  []int{},
+		Choruses:    []int{},
+		TotalVerses: len(verses),
+	}
+	for _, verse := range verses {
+		text.TotalLines += len(verse.Lines)
+		if verse.Chorus {
+			text.Choruses = append(text.Choruses, verse.Number)
+		}
 	}
 
-	end := start + limit
-	if end > len(verses) {
-		end = len(verses)
+	selected, limit := verses, u.textDefaultLimit
+	if q.Selects() {
+		selected = q.Select(verses)
+		limit = len(selected)
+	}
+	if q.Limit != nil {
+		limit = int(*q.Limit)
 	}
+	start := 0
+	if q.Offset != nil {
+		start = min(int(*q.Offset), len(selected))
+	}
+	end := min(start+limit, len(selected))
 
-	u.logger.Infof("Returning %d verses starting from %d", len(verses[start:end]), start)
-	return verses[start:end], nil
+	for _, verse := range selected[start:end] {
+		text.Verses = append(text.Verses, verse.Lines)
+		text.Numbers = append(text.Numbers, verse.Number)
+		text.FirstLines = append(text.FirstLines, verse.FirstLine)
+	}
+
+	u.logger.Infof("Returning %d verses starting from %d", len(text.Verses), start)
+	return text, nil
 }
 
-func (u *UseCase) CreateSong(req openapi.CreateSongBody, detail *openapi.SongDetail) (*openapi.Song, error) {
+func (u *UseCase) CreateSong(req openapi.CreateSongBody, detail *openapi.SongDetail) (*repository.VersionedSong, error) {
 	u.logger.Debugf("Creating song for group: %s, song: %s", req.Group, req.Song)
 	song := &openapi.Song{
 		Group:       req.Group,
@@ -120,36 +283,141 @@ func (u *UseCase) CreateSong(req openapi.CreateSongBody, detail *openapi.SongDet
 		Link:        detail.Link,
 	}
 
-	createdSong, err := u.repo.CreateSong(song)
+	var createdSong *repository.VersionedSong
+	err := u.repo.InTx(func(repo repository.Repository) error {
+		var err error
+		if createdSong, err = repo.CreateSong(song); err != nil {
+			return err
+		}
+		return u.saveEvent(repo, repository.EventSongCreated, createdSong.Song.Id, createdSong.Song.Group, createdSong.Version, createdSong.Song)
+	})
 	if err != nil {
 		u.logger.Errorf("error creating song: %v", err)
 		return nil, fmt.Errorf("creating song: %v", err)
 	}
 
+	u.suggestions.put(repository.SongName{ID: createdSong.Song.Id, Group: createdSong.Song.Group, Song: createdSong.Song.Song})
 	u.logger.Infof("Successfully created song for group: %s, song: %s", req.Group, req.Song)
 	return createdSong, nil
 }
 
-func (u *UseCase) UpdateSong(songID string, body *openapi.UpdateSongBody) (*openapi.Song, error) {
+// UpdateSong applies the changes; with ifVersion set it fails with ErrVersionMismatch if the song was changed meanwhile.
+func (u *UseCase) UpdateSong(songID string, body *openapi.UpdateSongBody, ifVersion *int64) (*repository.VersionedSong, error) {
 	u.logger.Debugf("Updating song with ID: %s", songID)
-	updatedSong, err := u.repo.UpdateSong(songID, body)
+	var updatedSong *repository.VersionedSong
+	err := u.repo.InTx(func(repo repository.Repository) error {
+		var err error
+		if updatedSong, err = repo.UpdateSong(songID, body, ifVersion); err != nil {
+			return err
+		}
+		return u.saveEvent(repo, repository.EventSongUpdated, updatedSong.Song.Id, updatedSong.Song.Group, updatedSong.Version, updatedSong.Song)
+	})
 	if err != nil {
 		u.logger.Errorf("error updating song: %v", err)
-		return nil, fmt.Errorf("updating song: %v", err)
+		return nil, fmt.Errorf("updating song: %w", err)
 	}
 
+	u.suggestions.put(repository.SongName{ID: updatedSong.Song.Id, Group: updatedSong.Song.Group, Song: updatedSong.Song.Song})
 	u.logger.Infof("Successfully updated song with ID: %s", songID)
 	return updatedSong, nil
 }
 
-func (u *UseCase) DeleteSong(songID string) error {
+// DeleteSong removes the song; with ifVersion set it fails with ErrVersionMismatch if the song was changed meanwhile.
+func (u *UseCase) DeleteSong(songID string, ifVersion *int64) error {
 	u.logger.Debugf("Deleting song with ID: %s", songID)
-	err := u.repo.DeleteSong(songID)
+	err := u.repo.InTx(func(repo repository.Repository) error {
+		group, err := repo.DeleteSong(songID, ifVersion)
+		if err != nil {
+			return err
+		}
+		return u.saveEvent(repo, repository.EventSongDeleted, songID, group, 0, nil)
+	})
 	if err != nil {
 		u.logger.Errorf("error deleting song: %v", err)
-		return fmt.Errorf("deleting song: %v", err)
+		return fmt.Errorf("deleting song: %w", err)
 	}
 
+	u.suggestions.remove(songID)
 	u.logger.Infof("Successfully deleted song with ID: %s", songID)
 	return nil
 }
+
+func (u *UseCase) SearchSongs(q *repository.SearchQuery) (*repository.SearchPage, error) {
+	u.logger.Debugf("Searching songs for: %s", q.Text)
+
+	query := *q
+	if query.Language == "" {
+		query.Language = detectLanguage(q.Text)
+	}
+	if len(query.Sort) == 0 {
+		query.Sort = repository.DefaultSearchSort
+	}
+	limit := pageLimit(q.Limit)
+	fetch := limit + 1
+	query.Limit = &fetch
+	query.Fields = columnsFor(q.Fields, q.Include)
+
+	hits, cursors, err := u.repo.SearchSongs(&query)
+	if err != nil {
+		u.logger.Errorf("error searching songs: %v", err)
+		return nil, fmt.Errorf("searching songs: %v", err)
+	}
+
+	page := &repository.SearchPage{}
+	page.Items, page.NextCursor, page.PrevCursor = trimPage(hits, cursors, limit, q.Cursor, false)
+	if err = u.expandHits(page.Items, q.Fields, q.Include); err != nil {
+		return nil, err
+	}
+
+	if len(page.Items) == 0 && q.Cursor == nil {
+		if page.DidYouMean, err = u.didYouMean(&q.Text, &q.Text); err != nil {
+			return nil, err
+		}
+	}
+
+	u.logger.Infof("Successfully found %d songs", len(page.Items))
+	return page, nil
+}
+
+// Suggest returns artists and songs whose names start with the query, in Latin or Cyrillic spelling.
+func (u *UseCase) Suggest(query string, limit int) []repository.Suggestion {
+	u.logger.Debugf("Suggesting names for: %s", query)
+	return u.suggestions.search(query, limit)
+}
+
+// RebuildSuggestions reloads the autocomplete index from the songs table.
+func (u *UseCase) RebuildSuggestions() error {
+	u.logger.Debug("Rebuilding suggestion index")
+	names, err := u.repo.GetSongNames()
+	if err != nil {
+		u.logger.Errorf("error rebuilding suggestion index: %v", err)
+		return fmt.Errorf("rebuilding suggestion index: %v", err)
+	}
+
+	u.suggestions.reset(names)
+	u.logger.Infof("Successfully indexed %d songs for suggestions", len(names))
+	return nil
+}
+
+// didYouMean suggests known names close to the ones that matched nothing, or nil if there are none.
+func (u *UseCase) didYouMean(group, song *string) (*repository.DidYouMean, error) {
+	suggestions, err := u.repo.SuggestNames(group, song, maxSuggestions)
+	if err != nil {
+		u.logger.Errorf("error suggesting names: %v", err)
+		return nil, fmt.Errorf("suggesting names: %v", err)
+	}
+	if len(suggestions.Group) == 0 && len(suggestions.Song) == 0 {
+		return nil, nil
+	}
+	return suggestions, nil
+}
+
+// detectLanguage picks the Russian configuration for queries containing Cyrillic letters and English otherwise.
+func detectLanguage(text string) string {
+	for _, r := range text {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return "ru"
+		}
+	}
+	return "en"
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	repository "effectiveMobile/internal"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// webhookPollInterval is how often due deliveries are looked for.
+	webhookPollInterval = time.Second
+	// webhookBatch is how many deliveries are claimed at once, and webhookWorkers how many are sent concurrently.
+	webhookBatch   = 32
+	webhookWorkers = 8
+	// maxWebhookBackoff bounds the delay between two attempts of a delivery.
+	maxWebhookBackoff = 6 * time.Hour
+	// maxWebhookError bounds the response body kept as the error of a failed attempt.
+	maxWebhookError = 512
+
+	headerWebhookEvent     = "X-Webhook-Event"
+	headerWebhookDelivery  = "X-Webhook-Delivery"
+	headerWebhookSignature = "X-Webhook-Signature"
+)
+
+// CreateWebhook subscribes a URL to song events and generates the secret its payloads are signed with.
+func (u *UseCase) CreateWebhook(target string, events []string) (*repository.Webhook, error) {
+	u.logger.Debugf("Creating webhook for URL: %s", target)
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", repository.ErrInvalidWebhook)
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: at least one event is required", repository.ErrInvalidWebhook)
+	}
+	for _, event := range events {
+		if !slices.Contains(repository.SongEvents, event) {
+			return nil, fmt.Errorf("%w: unknown event %q", repository.ErrInvalidWebhook, event)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generating webhook secret: %v", err)
+	}
+	events = slices.Clone(events)
+	slices.Sort(events)
+	webhook := &repository.Webhook{URL: target, Events: slices.Compact(events), Secret: hex.EncodeToString(secret)}
+	if err = u.repo.CreateWebhook(webhook); err != nil {
+		return nil, fmt.Errorf("creating webhook: %v", err)
+	}
+
+	u.logger.Infof("Successfully created webhook with ID: %s", webhook.ID)
+	return webhook, nil
+}
+
+func (u *UseCase) GetWebhooks() ([]*repository.Webhook, error) {
+	u.logger.Debug("Getting webhooks")
+	webhooks, err := u.repo.GetWebhooks()
+	if err != nil {
+		return nil, fmt.Errorf("getting webhooks: %v", err)
+	}
+	return webhooks, nil
+}
+
+func (u *UseCase) DeleteWebhook(webhookID string) error {
+	u.logger.Debugf("Deleting webhook with ID: %s", webhookID)
+	if err := u.repo.DeleteWebhook(webhookID); err != nil {
+		return fmt.Errorf("deleting webhook: %w", err)
+	}
+	u.logger.Infof("Successfully deleted webhook with ID: %s", webhookID)
+	return nil
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook, latest first.
+func (u *UseCase) GetWebhookDeliveries(webhookID string, status repository.DeliveryStatus, limit int) ([]*repository.WebhookDelivery, error) {
+	u.logger.Debugf("Getting deliveries of webhook with ID: %s", webhookID)
+	deliveries, err := u.repo.GetWebhookDeliveries(webhookID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("getting webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhook sends a delivery again, dead letters included.
+func (u *UseCase) RedeliverWebhook(webhookID, deliveryID string) (*repository.WebhookDelivery, error) {
+	u.logger.Debugf("Redelivering webhook delivery with ID: %s", deliveryID)
+	delivery, err := u.repo.RedeliverWebhookDelivery(webhookID, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("redelivering webhook delivery: %w", err)
+	}
+	u.logger.Infof("Scheduled redelivery of webhook delivery with ID: %s", deliveryID)
+	return delivery, nil
+}
+
+// RunWebhooks sends due deliveries until the process exits. Deliveries are scheduled by
+// RunOutboxRelay, so writes never wait for a webhook.
+func (u *UseCase) RunWebhooks() {
+	for range time.Tick(webhookPollInterval) {
+		// Deliveries are leased for longer than a send can take, so they are not sent twice.
+		deliveries, err := u.repo.ClaimWebhookDeliveries(webhookBatch, 2*u.webhookClient.Timeout)
+		if err != nil {
+			u.logger.Errorf("error claiming webhook deliveries: %v", err)
+			continue
+		}
+		parallel(len(deliveries), webhookWorkers, func(i int) {
+			u.deliver(deliveries[i])
+		})
+	}
+}
+
+// enqueueDeliveries schedules a delivery of the event to every webhook subscribed to it.
+func (u *UseCase) enqueueDeliveries(repo repository.Repository, event *repository.SongEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %v", event.Type, err)
+	}
+	scheduled, err := repo.EnqueueWebhookDeliveries(event.Type, payload)
+	if err != nil {
+		return err
+	}
+	u.logger.Debugf("Scheduled %d webhook deliveries of %s event %s", scheduled, event.Type, event.ID)
+	return nil
+}
+
+// deliver makes one attempt at sending a delivery and records its outcome. A failed delivery is
+// retried with exponential backoff, up to the configured number of attempts.
+func (u *UseCase) deliver(delivery *repository.WebhookDelivery) {
+	delivery.Attempts++
+	status, err := u.sendWebhook(delivery)
+	delivery.LastStatus = nil
+	if status != 0 {
+		delivery.LastStatus = &status
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case err == nil:
+		delivery.Status, delivery.DeliveredAt, delivery.LastError = repository.DeliveryDelivered, &now, nil
+	case delivery.Attempts >= u.webhookMaxAttempts:
+		reason := err.Error()
+		delivery.Status, delivery.LastError = repository.DeliveryDead, &reason
+		u.logger.Warnf("Webhook delivery %s is dead after %d attempts: %v", delivery.ID, delivery.Attempts, err)
+	default:
+		reason := err.Error()
+		delivery.LastError = &reason
+		delivery.NextAttemptAt = now.Add(u.retryDelay(delivery.Attempts))
+	}
+
+	if err = u.repo.SaveWebhookAttempt(delivery); err != nil {
+		u.logger.Errorf("error saving webhook attempt: %v", err)
+	}
+}
+
+// retryDelay is the delay after the given failed attempt: the configured backoff, doubled after
+// every further failure and capped at maxWebhookBackoff.
+func (u *UseCase) retryDelay(attempts int) time.Duration {
+	delay := u.webhookBackoff
+	for i := 1; i < attempts && delay < maxWebhookBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxWebhookBackoff)
+}
+
+// sendWebhook posts the payload, signed with the webhook secret. The signature header carries the
+// timestamp t and v1, the hex HMAC-SHA256 of "t.payload", so receivers can also reject replays.
+// Any 2xx status is a success.
+func (u *UseCase) sendWebhook(delivery *repository.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(delivery.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(delivery.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerWebhookEvent, delivery.Event)
+	req.Header.Set(headerWebhookDelivery, delivery.ID)
+	req.Header.Set(headerWebhookSignature, "t="+timestamp+",v1="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := u.webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookError))
+		return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS songs_release_date_sort_idx;
+DROP INDEX IF EXISTS songs_song_sort_idx;
+DROP INDEX IF EXISTS songs_group_sort_idx;
+
+ALTER TABLE songs DROP COLUMN IF EXISTS release_date_sort;
@@ -0,0 +1,11 @@
+-- release_date is stored as DD.MM.YYYY text, which does not sort chronologically.
+ALTER TABLE songs ADD COLUMN release_date_sort TEXT GENERATED ALWAYS AS (
+    CASE
+        WHEN release_date ~ '^\d{2}\.\d{2}\.\d{4}$'
+            THEN substr(release_date, 7, 4) || substr(release_date, 4, 2) || substr(release_date, 1, 2)
+    END
+) STORED;
+
+CREATE INDEX songs_group_sort_idx ON songs ((COALESCE("group", '')), id);
+CREATE INDEX songs_song_sort_idx ON songs ((COALESCE(song, '')), id);
+CREATE INDEX songs_release_date_sort_idx ON songs ((COALESCE(release_date_sort, '')), id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS songs_search_en_idx;
+DROP INDEX IF EXISTS songs_search_ru_idx;
+
+ALTER TABLE songs DROP COLUMN IF EXISTS search_en;
+ALTER TABLE songs DROP COLUMN IF EXISTS search_ru;
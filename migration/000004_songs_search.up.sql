@@ -0,0 +1,15 @@
+-- Title, artist and lyrics are weighted A, B and C for ranking.
+ALTER TABLE songs ADD COLUMN search_ru tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', COALESCE(song, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE("group", '')), 'B') ||
+    setweight(to_tsvector('russian', COALESCE("text", '')), 'C')
+) STORED;
+
+ALTER TABLE songs ADD COLUMN search_en tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(song, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE("group", '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE("text", '')), 'C')
+) STORED;
+
+CREATE INDEX songs_search_ru_idx ON songs USING GIN (search_ru);
+CREATE INDEX songs_search_en_idx ON songs USING GIN (search_en);
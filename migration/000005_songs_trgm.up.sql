@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Serve both the ILIKE substring filters and the % similarity operator.
+CREATE INDEX songs_group_trgm_idx ON songs USING GIN ("group" gin_trgm_ops);
+CREATE INDEX songs_song_trgm_idx ON songs USING GIN (song gin_trgm_ops);
@@ -0,0 +1 @@
+ALTER TABLE songs ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
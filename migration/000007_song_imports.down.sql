@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS song_imports;
+DROP INDEX IF EXISTS songs_group_song_idx;
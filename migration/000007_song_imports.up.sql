@@ -0,0 +1,9 @@
+-- Imports match existing songs by artist and title.
+CREATE INDEX songs_group_song_idx ON songs ("group", song);
+
+CREATE TABLE song_imports
+(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    report JSONB NOT NULL
+);
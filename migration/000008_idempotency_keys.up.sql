@@ -0,0 +1,14 @@
+-- Responses of write requests sent with an Idempotency-Key, replayed when the request is retried.
+-- A row without status belongs to a request still in flight.
+CREATE TABLE idempotency_keys
+(
+    key TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    locked_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    status INT,
+    header JSONB,
+    body BYTEA
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,13 @@
+-- The change feed. seq orders the events and is what clients resume from; every insert is
+-- announced on the song_events channel so all replicas can push it to their clients.
+CREATE TABLE song_events
+(
+    seq BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    song_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX song_events_occurred_at_idx ON song_events (occurred_at);
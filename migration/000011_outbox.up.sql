@@ -0,0 +1,11 @@
+-- Domain events written in the same transaction as the change they describe. The relay publishes
+-- them in seq order and deletes them once published, so the table only holds the backlog.
+CREATE TABLE outbox
+(
+    seq BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    song_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL
+);
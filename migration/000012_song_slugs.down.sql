@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS song_slugs;
+ALTER TABLE songs DROP COLUMN IF EXISTS slug;
+ALTER TABLE songs DROP COLUMN IF EXISTS artist_slug;
@@ -0,0 +1,17 @@
+-- The current slugs of a song, which address it as /artists/<artist_slug>/songs/<slug>.
+ALTER TABLE songs ADD COLUMN artist_slug TEXT;
+ALTER TABLE songs ADD COLUMN slug TEXT;
+
+-- Every slug a song has had, so links keep redirecting after a rename. A slug stays with its song
+-- until the song is deleted; other songs get a numbered one instead.
+CREATE TABLE song_slugs
+(
+    artist_slug TEXT NOT NULL,
+    slug TEXT NOT NULL,
+    song_id UUID NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (artist_slug, slug)
+);
+
+CREATE INDEX song_slugs_song_idx ON song_slugs (song_id);
+CREATE INDEX songs_without_slug_idx ON songs (id) WHERE slug IS NULL;
@@ -0,0 +1,382 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.3
+// source: songs/v1/songs.proto
+
+package songsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SongService_GetSongDetail_FullMethodName = "/songs.v1.SongService/GetSongDetail"
+	SongService_GetSongs_FullMethodName      = "/songs.v1.SongService/GetSongs"
+	SongService_StreamSongs_FullMethodName   = "/songs.v1.SongService/StreamSongs"
+	SongService_GetSongText_FullMethodName   = "/songs.v1.SongService/GetSongText"
+	SongService_CreateSong_FullMethodName    = "/songs.v1.SongService/CreateSong"
+	SongService_UpdateSong_FullMethodName    = "/songs.v1.SongService/UpdateSong"
+	SongService_DeleteSong_FullMethodName    = "/songs.v1.SongService/DeleteSong"
+)
+
+// SongServiceClient is the client API for SongService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SongService mirrors the REST API of the music catalog.
+//
+// Errors carry gRPC status codes: NOT_FOUND for unknown songs, ABORTED when the
+// if_version of a write is not current, INVALID_ARGUMENT for malformed requests
+// and UNAVAILABLE when the song detail service cannot be reached.
+type SongServiceClient interface {
+	// GetSongDetail returns the stored details of a song.
+	GetSongDetail(ctx context.Context, in *GetSongDetailRequest, opts ...grpc.CallOption) (*SongDetail, error)
+	// GetSongs returns a page of songs matching the filter.
+	GetSongs(ctx context.Context, in *GetSongsRequest, opts ...grpc.CallOption) (*GetSongsResponse, error)
+	// StreamSongs sends every song matching the filter, read from a server-side
+	// cursor, so large listings need neither paging nor buffering.
+	StreamSongs(ctx context.Context, in *StreamSongsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Song], error)
+	// GetSongText returns a page of the lyrics split into verses.
+	GetSongText(ctx context.Context, in *GetSongTextRequest, opts ...grpc.CallOption) (*GetSongTextResponse, error)
+	// CreateSong creates a song with the details fetched from the song detail service.
+	CreateSong(ctx context.Context, in *CreateSongRequest, opts ...grpc.CallOption) (*Song, error)
+	// UpdateSong changes the fields that are set.
+	UpdateSong(ctx context.Context, in *UpdateSongRequest, opts ...grpc.CallOption) (*Song, error)
+	// DeleteSong removes a song.
+	DeleteSong(ctx context.Context, in *DeleteSongRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type songServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSongServiceClient(cc grpc.ClientConnInterface) SongServiceClient {
+	return &songServiceClient{cc}
+}
+
+func (c *songServiceClient) GetSongDetail(ctx context.Context, in *GetSongDetailRequest, opts ...grpc.CallOption) (*SongDetail, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SongDetail)
+	err := c.cc.Invoke(ctx, SongService_GetSongDetail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *songServiceClient) GetSongs(ctx context.Context, in *GetSongsRequest, opts ...grpc.CallOption) (*GetSongsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSongsResponse)
+	err := c.cc.Invoke(ctx, SongService_GetSongs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *songServiceClient) StreamSongs(ctx context.Context, in *StreamSongsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Song], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SongService_ServiceDesc.Streams[0], SongService_StreamSongs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamSongsRequest, Song]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SongService_StreamSongsClient = grpc.ServerStreamingClient[Song]
+
+func (c *songServiceClient) GetSongText(ctx context.Context, in *GetSongTextRequest, opts ...grpc.CallOption) (*GetSongTextResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSongTextResponse)
+	err := c.cc.Invoke(ctx, SongService_GetSongText_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *songServiceClient) CreateSong(ctx context.Context, in *CreateSongRequest, opts ...grpc.CallOption) (*Song, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Song)
+	err := c.cc.Invoke(ctx, SongService_CreateSong_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *songServiceClient) UpdateSong(ctx context.Context, in *UpdateSongRequest, opts ...grpc.CallOption) (*Song, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Song)
+	err := c.cc.Invoke(ctx, SongService_UpdateSong_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *songServiceClient) DeleteSong(ctx context.Context, in *DeleteSongRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, SongService_DeleteSong_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SongServiceServer is the server API for SongService service.
+// All implementations must embed UnimplementedSongServiceServer
+// for forward compatibility.
+//
+// SongService mirrors the REST API of the music catalog.
+//
+// Errors carry gRPC status codes: NOT_FOUND for unknown songs, ABORTED when the
+// if_version of a write is not current, INVALID_ARGUMENT for malformed requests
+// and UNAVAILABLE when the song detail service cannot be reached.
+type SongServiceServer interface {
+	// GetSongDetail returns the stored details of a song.
+	GetSongDetail(context.Context, *GetSongDetailRequest) (*SongDetail, error)
+	// GetSongs returns a page of songs matching the filter.
+	GetSongs(context.Context, *GetSongsRequest) (*GetSongsResponse, error)
+	// StreamSongs sends every song matching the filter, read from a server-side
+	// cursor, so large listings need neither paging nor buffering.
+	StreamSongs(*StreamSongsRequest, grpc.ServerStreamingServer[Song]) error
+	// GetSongText returns a page of the lyrics split into verses.
+	GetSongText(context.Context, *GetSongTextRequest) (*GetSongTextResponse, error)
+	// CreateSong creates a song with the details fetched from the song detail service.
+	CreateSong(context.Context, *CreateSongRequest) (*Song, error)
+	// UpdateSong changes the fields that are set.
+	UpdateSong(context.Context, *UpdateSongRequest) (*Song, error)
+	// DeleteSong removes a song.
+	DeleteSong(context.Context, *DeleteSongRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedSongServiceServer()
+}
+
+// UnimplementedSongServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSongServiceServer struct{}
+
+func (UnimplementedSongServiceServer) GetSongDetail(context.Context, *GetSongDetailRequest) (*SongDetail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSongDetail not implemented")
+}
+func (UnimplementedSongServiceServer) GetSongs(context.Context, *GetSongsRequest) (*GetSongsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSongs not implemented")
+}
+func (UnimplementedSongServiceServer) StreamSongs(*StreamSongsRequest, grpc.ServerStreamingServer[Song]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSongs not implemented")
+}
+func (UnimplementedSongServiceServer) GetSongText(context.Context, *GetSongTextRequest) (*GetSongTextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSongText not implemented")
+}
+func (UnimplementedSongServiceServer) CreateSong(context.Context, *CreateSongRequest) (*Song, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSong not implemented")
+}
+func (UnimplementedSongServiceServer) UpdateSong(context.Context, *UpdateSongRequest) (*Song, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSong not implemented")
+}
+func (UnimplementedSongServiceServer) DeleteSong(context.Context, *DeleteSongRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSong not implemented")
+}
+func (UnimplementedSongServiceServer) mustEmbedUnimplementedSongServiceServer() {}
+func (UnimplementedSongServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeSongServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SongServiceServer will
+// result in compilation errors.
+type UnsafeSongServiceServer interface {
+	mustEmbedUnimplementedSongServiceServer()
+}
+
+func RegisterSongServiceServer(s grpc.ServiceRegistrar, srv SongServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSongServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SongService_ServiceDesc, srv)
+}
+
+func _SongService_GetSongDetail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSongDetailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongServiceServer).GetSongDetail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongService_GetSongDetail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongServiceServer).GetSongDetail(ctx, req.(*GetSongDetailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SongService_GetSongs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSongsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongServiceServer).GetSongs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongService_GetSongs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongServiceServer).GetSongs(ctx, req.(*GetSongsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SongService_StreamSongs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamSongsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SongServiceServer).StreamSongs(m, &grpc.GenericServerStream[StreamSongsRequest, Song]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SongService_StreamSongsServer = grpc.ServerStreamingServer[Song]
+
+func _SongService_GetSongText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSongTextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongServiceServer).GetSongText(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongService_GetSongText_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongServiceServer).GetSongText(ctx, req.(*GetSongTextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SongService_CreateSong_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSongRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongServiceServer).CreateSong(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongService_CreateSong_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongServiceServer).CreateSong(ctx, req.(*CreateSongRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SongService_UpdateSong_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSongRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongServiceServer).UpdateSong(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongService_UpdateSong_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongServiceServer).UpdateSong(ctx, req.(*UpdateSongRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SongService_DeleteSong_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSongRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongServiceServer).DeleteSong(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongService_DeleteSong_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongServiceServer).DeleteSong(ctx, req.(*DeleteSongRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SongService_ServiceDesc is the grpc.ServiceDesc for SongService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SongService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "songs.v1.SongService",
+	HandlerType: (*SongServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSongDetail",
+			Handler:    _SongService_GetSongDetail_Handler,
+		},
+		{
+			MethodName: "GetSongs",
+			Handler:    _SongService_GetSongs_Handler,
+		},
+		{
+			MethodName: "GetSongText",
+			Handler:    _SongService_GetSongText_Handler,
+		},
+		{
+			MethodName: "CreateSong",
+			Handler:    _SongService_CreateSong_Handler,
+		},
+		{
+			MethodName: "UpdateSong",
+			Handler:    _SongService_UpdateSong_Handler,
+		},
+		{
+			MethodName: "DeleteSong",
+			Handler:    _SongService_DeleteSong_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamSongs",
+			Handler:       _SongService_StreamSongs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "songs/v1/songs.proto",
+}
@@ -0,0 +1,37 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/csv"
+)
+
+// Table is implemented by values that can be written as rows, such as listings.
+type Table interface {
+	Table() (header []string, rows [][]string)
+}
+
+// CSV writes Tables as a header row followed by their rows. Other values are not supported.
+type CSV struct{}
+
+func (CSV) Marshal(v any) ([]byte, error) {
+	table, ok := v.(Table)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	header, rows := table.Table()
+
+	var out bytes.Buffer
+	w := csv.NewWriter(&out)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (CSV) Supports(v any) bool {
+	_, ok := v.(Table)
+	return ok
+}
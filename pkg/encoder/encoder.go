@@ -0,0 +1,77 @@
+package encoder
+
+import (
+	"encoding/xml"
+	"errors"
+)
+
+// ErrUnsupported is returned by encoders asked to marshal a value they cannot represent.
+var ErrUnsupported = errors.New("value cannot be represented in this media type")
+
+// Encoder marshals response bodies in a media type.
+type Encoder interface {
+	Marshal(v any) ([]byte, error)
+	// Supports reports whether Marshal can represent v.
+	Supports(v any) bool
+}
+
+// MarshalFunc adapts a function able to marshal any value, like json.Marshal, to an Encoder.
+type MarshalFunc func(v any) ([]byte, error)
+
+func (f MarshalFunc) Marshal(v any) ([]byte, error) {
+	return f(v)
+}
+
+func (f MarshalFunc) Supports(any) bool {
+	return true
+}
+
+// XML marshals values with encoding/xml, following their xml struct tags, after the XML declaration.
+var XML = MarshalFunc(func(v any) ([]byte, error) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+})
+
+// Registry holds the encoders by media type, in the order they were registered.
+type Registry struct {
+	mediaTypes []string
+	encoders   map[string]Encoder
+}
+
+func NewRegistry() *Registry {
+	return &Registry{encoders: make(map[string]Encoder)}
+}
+
+// Register adds the encoder of the media type, replacing the previous one. When Accept allows
+// several media types equally, the one registered first is served, so the first registration is
+// the default.
+func (r *Registry) Register(mediaType string, enc Encoder) *Registry {
+	if _, ok := r.encoders[mediaType]; !ok {
+		r.mediaTypes = append(r.mediaTypes, mediaType)
+	}
+	r.encoders[mediaType] = enc
+	return r
+}
+
+// Offers lists the media types whose encoder can represent v, in order of preference.
+func (r *Registry) Offers(v any) []string {
+	var offers []string
+	for _, mediaType := range r.mediaTypes {
+		if r.encoders[mediaType].Supports(v) {
+			offers = append(offers, mediaType)
+		}
+	}
+	return offers
+}
+
+// Marshal encodes v in the media type.
+func (r *Registry) Marshal(mediaType string, v any) ([]byte, error) {
+	enc, ok := r.encoders[mediaType]
+	if !ok || !enc.Supports(v) {
+		return nil, ErrUnsupported
+	}
+	return enc.Marshal(v)
+}
@@ -0,0 +1,26 @@
+package encoder
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MessagePack marshals values following their json struct tags, so fields are named and
+// omitted as in JSON. Integers take the smallest representation holding them.
+type MessagePack struct{}
+
+func (MessagePack) Marshal(v any) ([]byte, error) {
+	var out bytes.Buffer
+	enc := msgpack.NewEncoder(&out)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (MessagePack) Supports(any) bool {
+	return true
+}
@@ -1,7 +1,9 @@
 package logger
 
 import (
+	"context"
 	"effectiveMobile/config"
+	"effectiveMobile/pkg/requestid"
 	"fmt"
 	"log/slog"
 	"os"
@@ -40,8 +42,9 @@ type Logger interface {
 
 // Logger
 type ApiLogger struct {
-	cfg    *config.Config
-	logger *slog.Logger
+	cfg       *config.Config
+	logger    *slog.Logger
+	requestID string
 }
 
 // App Logger constructor
@@ -55,57 +58,67 @@ func (a *ApiLogger) InitLogger() error {
 	return nil
 }
 
+// WithContext returns a logger adding the request ID carried by ctx to every line, or a itself
+// when ctx has none or a already adds it.
+func (a *ApiLogger) WithContext(ctx context.Context) *ApiLogger {
+	id := requestid.FromContext(ctx)
+	if id == "" || id == a.requestID {
+		return a
+	}
+	return &ApiLogger{cfg: a.cfg, logger: a.logger.With("request_id", id), requestID: id}
+}
+
 func (a *ApiLogger) Debug(msg string) {
-	go a.logger.Debug(msg)
+	a.logger.Debug(msg)
 }
 
 func (a *ApiLogger) Debugf(template string, args ...interface{}) {
-	go a.logger.Debug(fmt.Sprintf(template, args...))
+	a.logger.Debug(fmt.Sprintf(template, args...))
 }
 
 func (a *ApiLogger) Info(msg string) {
-	go a.logger.Info(msg)
+	a.logger.Info(msg)
 }
 
 func (a *ApiLogger) Infof(template string, args ...interface{}) {
-	go a.logger.Info(fmt.Sprintf(template, args...))
+	a.logger.Info(fmt.Sprintf(template, args...))
 }
 
 func (a *ApiLogger) Warn(msg string) {
-	go a.logger.Warn(msg)
+	a.logger.Warn(msg)
 }
 
 func (a *ApiLogger) Warnf(template string, args ...interface{}) {
-	go a.logger.Warn(fmt.Sprintf(template, args...))
+	a.logger.Warn(fmt.Sprintf(template, args...))
 }
 
 func (a *ApiLogger) Error(err error) {
-	go a.logger.Error(err.Error())
+	a.logger.Error(err.Error())
 }
 
 func (a *ApiLogger) Errorf(template string, args ...interface{}) {
-	go a.logger.Error(fmt.Sprintf(template, args...))
+	a.logger.Error(fmt.Sprintf(template, args...))
 }
 
 func (a *ApiLogger) Panic(msg string) {
-	go a.logger.Error(msg)
+	a.logger.Error(msg)
 }
 
 func (a *ApiLogger) Panicf(template string, args ...interface{}) {
-	go a.logger.Error(fmt.Sprintf(template, args...))
+	a.logger.Error(fmt.Sprintf(template, args...))
 }
 
 func (a *ApiLogger) Fatal(msg string) {
-	go a.logger.Error(msg)
+	a.logger.Error(msg)
 }
 
 func (a *ApiLogger) Fatalf(template string, args ...interface{}) {
-	go a.logger.Error(fmt.Sprintf(template, args...))
+	a.logger.Error(fmt.Sprintf(template, args...))
 }
 
 func (a *ApiLogger) ErrorFull(err error) {
 	pc, _, line, _ := runtime.Caller(1)
 	det := runtime.FuncForPC(pc)
 	msg := fmt.Sprintf("ERROR:\n%s :: %d :: %s", det.Name(), line, err.Error())
-	go a.logger.Error(msg)
+	a.logger.Error(msg)
 }
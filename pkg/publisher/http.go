@@ -0,0 +1,63 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	HeaderEventID      = "X-Event-ID"
+	HeaderEventSubject = "X-Event-Subject"
+	HeaderEventKey     = "X-Event-Key"
+
+	// maxSinkError bounds the response body kept as the error of a rejected message.
+	maxSinkError = 512
+)
+
+// HTTP posts every message as a JSON request to a sink, with its ID, subject and key in headers.
+// Any 2xx status means the message was accepted.
+type HTTP struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTP(sink string, timeout time.Duration) (*HTTP, error) {
+	parsed, err := url.Parse(sink)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("HTTP sink %q must be an absolute http or https URL", sink)
+	}
+	return &HTTP{url: sink, client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (p *HTTP) Publish(ctx context.Context, msg Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(msg.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, msg.ID)
+	req.Header.Set(HeaderEventSubject, msg.Subject)
+	req.Header.Set(HeaderEventKey, msg.Key)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxSinkError))
+		return fmt.Errorf("sink answered %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+func (p *HTTP) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,59 @@
+package publisher
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory delivers messages to subscribers in the same process. Publish waits for every
+// subscriber to take the message, so none is lost while it keeps reading.
+type Memory struct {
+	mu          sync.RWMutex
+	subscribers map[chan Message]struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{subscribers: make(map[chan Message]struct{})}
+}
+
+// Subscribe returns the messages published from now on. cancel ends the subscription.
+func (m *Memory) Subscribe(buffer int) (<-chan Message, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch := make(chan Message, buffer)
+	m.subscribers[ch] = struct{}{}
+	return ch, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.subscribers[ch]; ok {
+			delete(m.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (m *Memory) Publish(ctx context.Context, msg Message) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for ch := range m.subscribers {
+		select {
+		case ch <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for ch := range m.subscribers {
+		delete(m.subscribers, ch)
+		close(ch)
+	}
+	return nil
+}
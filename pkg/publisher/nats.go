@@ -0,0 +1,42 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATS publishes every message on its subject to a NATS-compatible broker and flushes, so Publish
+// returns once the server has received it. Messages carry their ID as Nats-Msg-Id, which lets a
+// JetStream stream on the subjects drop the duplicates of a redelivery.
+type NATS struct {
+	conn *nats.Conn
+}
+
+func NewNATS(url string, timeout time.Duration) (*NATS, error) {
+	if url == "" {
+		url = nats.DefaultURL
+	}
+	conn, err := nats.Connect(url, nats.Name("effectiveMobile outbox"), nats.Timeout(timeout), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to NATS: %v", err)
+	}
+	return &NATS{conn: conn}, nil
+}
+
+func (p *NATS) Publish(ctx context.Context, msg Message) error {
+	m := nats.NewMsg(msg.Subject)
+	m.Header.Set(nats.MsgIdHdr, msg.ID)
+	m.Header.Set(HeaderEventKey, msg.Key)
+	m.Data = msg.Body
+	if err := p.conn.PublishMsg(m); err != nil {
+		return err
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+func (p *NATS) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,38 @@
+package publisher
+
+import (
+	"context"
+	"effectiveMobile/config"
+	"fmt"
+)
+
+// Message is an event handed to a broker.
+type Message struct {
+	// ID is unique per event, so consumers can drop the duplicates of an at-least-once delivery.
+	ID string
+	// Subject names the kind of event, e.g. songs.song.created.
+	Subject string
+	// Key groups the events that must be consumed in order, e.g. the song ID.
+	Key  string
+	Body []byte
+}
+
+// Publisher hands events over to a broker. Publish returns once the broker has accepted the
+// message; an error means it may or may not have been, so it is published again.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// New builds the publisher selected in the configuration.
+func New(cfg *config.Config) (Publisher, error) {
+	switch cfg.Outbox.Publisher {
+	case "memory":
+		return NewMemory(), nil
+	case "http":
+		return NewHTTP(cfg.Outbox.URL, cfg.Outbox.Timeout)
+	case "nats":
+		return NewNATS(cfg.Outbox.URL, cfg.Outbox.Timeout)
+	}
+	return nil, fmt.Errorf("unknown outbox publisher %q, expected memory, http or nats", cfg.Outbox.Publisher)
+}
@@ -0,0 +1,38 @@
+package requestid
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the request ID, both ways: clients may send their own, and every response
+// returns the one used.
+const Header = "X-Request-ID"
+
+// validID limits IDs taken from clients to what can be logged and echoed back safely.
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:/+=-]{1,128}$`)
+
+type contextKey struct{}
+
+// New generates a request ID.
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether an ID sent by a client can be used as is.
+func Valid(id string) bool {
+	return validID.MatchString(id)
+}
+
+// NewContext returns a copy of ctx carrying the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or "" outside of a request.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
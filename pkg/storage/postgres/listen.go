@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Listen calls fn with the payload of every notification sent on channel until ctx is done or the
+// connection fails. listening is called once notifications are being received. It holds a connection of its own for as long as it runs, which is closed
+// afterwards rather than handed back to the pool, so no pooled connection stays subscribed.
+func (p Pool) Listen(ctx context.Context, channel string, listening func(), fn func(payload string)) error {
+	conn, err := p.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	listener := conn.Hijack()
+	defer listener.Close(context.Background())
+
+	if _, err = listener.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	listening()
+	for {
+		notification, err := listener.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		fn(notification.Payload)
+	}
+}
@@ -28,11 +28,16 @@ type Postgres interface {
 	Select(dest interface{}, query string, args ...interface{}) error
 	Exec(query string, args ...any) (pgconn.CommandTag, error)
 	QueryRow(query string, args ...interface{}) pgx.Row
+	CopyFrom(table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error)
+	Listen(ctx context.Context, channel string, listening func(), fn func(payload string)) error
+	// WithContext returns the connection running every statement with ctx.
+	WithContext(ctx context.Context) Postgres
 	TxRunner
 }
 
 type Pool struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	ctx context.Context
 }
 
 func InitPsqlDB(c *config.Config) (Postgres, error) {
@@ -66,7 +71,7 @@ func InitPsqlDB(c *config.Config) (Postgres, error) {
 		return nil, err
 	}
 
-	return &Pool{db: result}, nil
+	return &Pool{db: result, ctx: context.Background()}, nil
 }
 
 func (p Pool) Stats() *pgxpool.Stat {
@@ -78,11 +83,11 @@ func (p Pool) Begin(ctx context.Context) (pgx.Tx, error) {
 }
 
 func (p Pool) Query(query string, args ...any) (pgx.Rows, error) {
-	return p.db.Query(context.Background(), query, args...)
+	return p.db.Query(p.ctx, query, args...)
 }
 
 func (p Pool) Get(dest interface{}, query string, args ...interface{}) error {
-	rows, err := p.db.Query(context.Background(), query, args...)
+	rows, err := p.db.Query(p.ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -90,7 +95,7 @@ func (p Pool) Get(dest interface{}, query string, args ...interface{}) error {
 }
 
 func (p Pool) Select(dest interface{}, query string, args ...interface{}) error {
-	rows, err := p.db.Query(context.Background(), query, args...)
+	rows, err := p.db.Query(p.ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -98,9 +103,18 @@ func (p Pool) Select(dest interface{}, query string, args ...interface{}) error
 }
 
 func (p Pool) Exec(query string, args ...interface{}) (pgconn.CommandTag, error) {
-	return p.db.Exec(context.Background(), query, args...)
+	return p.db.Exec(p.ctx, query, args...)
 }
 
 func (p Pool) QueryRow(query string, args ...interface{}) pgx.Row {
-	return p.db.QueryRow(context.Background(), query, args...)
+	return p.db.QueryRow(p.ctx, query, args...)
+}
+
+func (p Pool) CopyFrom(table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error) {
+	return p.db.CopyFrom(p.ctx, table, columns, src)
+}
+
+func (p Pool) WithContext(ctx context.Context) Postgres {
+	p.ctx = ctx
+	return &p
 }
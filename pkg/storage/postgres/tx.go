@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -74,9 +75,65 @@ func (p Tx) Select(ctx context.Context, dest interface{}, query string, args ...
 }
 
 func (p Tx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
-	return p.Exec(ctx, sql, arguments...)
+	return p.db.Exec(ctx, sql, arguments...)
 }
 
 func (p Tx) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
 	return p.db.QueryRow(ctx, query, args...)
 }
+
+func (p Tx) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error) {
+	return p.db.CopyFrom(ctx, table, columns, src)
+}
+
+// Conn exposes the transaction through the Postgres interface, running every statement with ctx,
+// so code written against a pool can run unchanged inside the transaction.
+func (p Tx) Conn(ctx context.Context) Postgres {
+	return txConn{tx: p, ctx: ctx}
+}
+
+type txConn struct {
+	tx  Tx
+	ctx context.Context
+}
+
+func (c txConn) Stats() *pgxpool.Stat {
+	return c.tx.Stats()
+}
+
+func (c txConn) Begin(ctx context.Context) (pgx.Tx, error) {
+	return c.tx.Begin(ctx)
+}
+
+func (c txConn) Query(query string, args ...any) (pgx.Rows, error) {
+	return c.tx.Query(c.ctx, query, args...)
+}
+
+func (c txConn) Get(dest interface{}, query string, args ...interface{}) error {
+	return c.tx.Get(c.ctx, dest, query, args...)
+}
+
+func (c txConn) Select(dest interface{}, query string, args ...interface{}) error {
+	return c.tx.Select(c.ctx, dest, query, args...)
+}
+
+func (c txConn) Exec(query string, args ...any) (pgconn.CommandTag, error) {
+	return c.tx.Exec(c.ctx, query, args...)
+}
+
+func (c txConn) QueryRow(query string, args ...interface{}) pgx.Row {
+	return c.tx.QueryRow(c.ctx, query, args...)
+}
+
+func (c txConn) CopyFrom(table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error) {
+	return c.tx.CopyFrom(c.ctx, table, columns, src)
+}
+
+func (c txConn) WithContext(ctx context.Context) Postgres {
+	return txConn{tx: c.tx, ctx: ctx}
+}
+
+// Listen is not available inside a transaction: notifications are only delivered once it commits.
+func (c txConn) Listen(ctx context.Context, channel string, listening func(), fn func(payload string)) error {
+	return errors.New("cannot listen inside a transaction")
+}
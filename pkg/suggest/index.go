@@ -0,0 +1,224 @@
+package suggest
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Item is a value to index under Text, ranked by Weight.
+type Item[T any] struct {
+	ID     string
+	Text   string
+	Weight int
+	Value  T
+}
+
+type entry[T any] struct {
+	item  Item[T]
+	terms []string
+}
+
+type term struct {
+	key   string
+	id    string
+	first bool
+}
+
+// shortPrefix is the longest prefix whose results are cached: short prefixes match
+// a large part of the index, while longer ones narrow the scanned range enough.
+const shortPrefix = 2
+
+// Index is an in-memory prefix index. Every item is reachable by a prefix of
+// its normalized text or of any word in it. It is safe for concurrent use.
+type Index[T any] struct {
+	mu      sync.RWMutex
+	terms   []term
+	entries map[string]*entry[T]
+
+	cacheMu    sync.Mutex
+	cache      map[cacheKey][]T
+	generation uint64
+}
+
+type cacheKey struct {
+	prefix string
+	limit  int
+}
+
+func NewIndex[T any]() *Index[T] {
+	return &Index[T]{entries: make(map[string]*entry[T]), cache: make(map[cacheKey][]T)}
+}
+
+func (x *Index[T]) invalidate() {
+	x.cacheMu.Lock()
+	clear(x.cache)
+	x.generation++
+	x.cacheMu.Unlock()
+}
+
+// Reset replaces the whole index content.
+func (x *Index[T]) Reset(items []Item[T]) {
+	entries := make(map[string]*entry[T], len(items))
+	var terms []term
+	for _, item := range items {
+		e := &entry[T]{item: item, terms: termsOf(item.Text)}
+		entries[item.ID] = e
+		for i, key := range e.terms {
+			terms = append(terms, term{key: key, id: item.ID, first: i == 0})
+		}
+	}
+	slices.SortFunc(terms, compareTerms)
+
+	x.mu.Lock()
+	x.terms, x.entries = terms, entries
+	x.mu.Unlock()
+	x.invalidate()
+}
+
+// Put adds the item or replaces the one with the same ID.
+func (x *Index[T]) Put(item Item[T]) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	defer x.invalidate()
+
+	x.remove(item.ID)
+	e := &entry[T]{item: item, terms: termsOf(item.Text)}
+	x.entries[item.ID] = e
+	for i, key := range e.terms {
+		t := term{key: key, id: item.ID, first: i == 0}
+		pos, _ := slices.BinarySearchFunc(x.terms, t, compareTerms)
+		x.terms = slices.Insert(x.terms, pos, t)
+	}
+}
+
+// Remove drops the item with the given ID, if present.
+func (x *Index[T]) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	defer x.invalidate()
+	x.remove(id)
+}
+
+func (x *Index[T]) remove(id string) {
+	e, ok := x.entries[id]
+	if !ok {
+		return
+	}
+	delete(x.entries, id)
+	for i, key := range e.terms {
+		t := term{key: key, id: id, first: i == 0}
+		if pos, found := slices.BinarySearchFunc(x.terms, t, compareTerms); found {
+			x.terms = slices.Delete(x.terms, pos, pos+1)
+		}
+	}
+}
+
+// Get returns the item with the given ID.
+func (x *Index[T]) Get(id string) (Item[T], bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	e, ok := x.entries[id]
+	if !ok {
+		return Item[T]{}, false
+	}
+	return e.item, true
+}
+
+// Search returns up to limit values whose text has a word starting with query.
+// Items whose whole text starts with query rank first, then heavier items.
+func (x *Index[T]) Search(query string, limit int) []T {
+	prefix := Normalize(query)
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+	if len(prefix) > shortPrefix {
+		return x.search(prefix, limit)
+	}
+
+	key := cacheKey{prefix: prefix, limit: limit}
+	x.cacheMu.Lock()
+	values, ok := x.cache[key]
+	generation := x.generation
+	x.cacheMu.Unlock()
+	if ok {
+		return values
+	}
+
+	values = x.search(prefix, limit)
+	x.cacheMu.Lock()
+	// Results computed while the index changed may be stale and are not cached.
+	if generation == x.generation {
+		x.cache[key] = values
+	}
+	x.cacheMu.Unlock()
+	return values
+}
+
+func (x *Index[T]) search(prefix string, limit int) []T {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	type match struct {
+		e     *entry[T]
+		first bool
+	}
+	matches := make(map[string]*match)
+	start := sort.Search(len(x.terms), func(i int) bool { return x.terms[i].key >= prefix })
+	for _, t := range x.terms[start:] {
+		if !strings.HasPrefix(t.key, prefix) {
+			break
+		}
+		if m, ok := matches[t.id]; ok {
+			m.first = m.first || t.first
+			continue
+		}
+		matches[t.id] = &match{e: x.entries[t.id], first: t.first}
+	}
+
+	ranked := make([]*match, 0, len(matches))
+	for _, m := range matches {
+		ranked = append(ranked, m)
+	}
+	slices.SortFunc(ranked, func(a, b *match) int {
+		if a.first != b.first {
+			if a.first {
+				return -1
+			}
+			return 1
+		}
+		if a.e.item.Weight != b.e.item.Weight {
+			return b.e.item.Weight - a.e.item.Weight
+		}
+		return strings.Compare(a.e.item.Text, b.e.item.Text)
+	})
+
+	values := make([]T, 0, min(limit, len(ranked)))
+	for _, m := range ranked[:min(limit, len(ranked))] {
+		values = append(values, m.e.item.Value)
+	}
+	return values
+}
+
+// termsOf returns the normalized text followed by its suffixes starting at each later word.
+func termsOf(text string) []string {
+	normalized := Normalize(text)
+	if normalized == "" {
+		return nil
+	}
+	terms := []string{normalized}
+	for i := 0; i < len(normalized); i++ {
+		if normalized[i] == ' ' {
+			terms = append(terms, normalized[i+1:])
+		}
+	}
+	return terms
+}
+
+func compareTerms(a, b term) int {
+	if c := strings.Compare(a.key, b.key); c != 0 {
+		return c
+	}
+	return strings.Compare(a.id, b.id)
+}
@@ -0,0 +1,141 @@
+package suggest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestIndex() *Index[string] {
+	x := NewIndex[string]()
+	x.Reset([]Item[string]{
+		{ID: "1", Text: "Muse", Weight: 1, Value: "Muse"},
+		{ID: "2", Text: "Metallica", Weight: 5, Value: "Metallica"},
+		{ID: "3", Text: "Knights of Cydonia", Weight: 1, Value: "Knights of Cydonia"},
+		{ID: "4", Text: "Кино", Weight: 3, Value: "Кино"},
+		{ID: "5", Text: "Sabbath Bloody Sabbath", Weight: 1, Value: "Sabbath Bloody Sabbath"},
+		{ID: "6", Text: "Black Sabbath", Weight: 10, Value: "Black Sabbath"},
+	})
+	return x
+}
+
+func TestIndexSearch(t *testing.T) {
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{query: "m", limit: 10, want: []string{"Metallica", "Muse"}},
+		{query: "MU", limit: 10, want: []string{"Muse"}},
+		{query: "cyd", limit: 10, want: []string{"Knights of Cydonia"}},
+		{query: "of cy", limit: 10, want: []string{"Knights of Cydonia"}},
+		{query: "kino", limit: 10, want: []string{"Кино"}},
+		{query: "Ки", limit: 10, want: []string{"Кино"}},
+		// A match on the whole text ranks before a heavier match on a later word.
+		{query: "sab", limit: 10, want: []string{"Sabbath Bloody Sabbath", "Black Sabbath"}},
+		{query: "b", limit: 10, want: []string{"Black Sabbath", "Sabbath Bloody Sabbath"}},
+		{query: "m", limit: 1, want: []string{"Metallica"}},
+		{query: "zzz", limit: 10, want: []string{}},
+		{query: "m", limit: 0, want: nil},
+		{query: " ,. ", limit: 10, want: nil},
+	}
+	x := newTestIndex()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := x.Search(tt.query, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Search(%q, %d) = %q, want %q", tt.query, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexSearchTiesByText(t *testing.T) {
+	x := NewIndex[string]()
+	x.Reset([]Item[string]{
+		{ID: "1", Text: "Beta", Value: "Beta"},
+		{ID: "2", Text: "Bee Gees", Value: "Bee Gees"},
+		{ID: "3", Text: "Berlin", Value: "Berlin"},
+	})
+	if got, want := x.Search("be", 10), []string{"Bee Gees", "Berlin", "Beta"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Search = %q, want %q", got, want)
+	}
+}
+
+func TestIndexPutReplaces(t *testing.T) {
+	x := newTestIndex()
+	// Fill the cache of the short prefixes first, so stale results would show.
+	x.Search("mu", 10)
+	x.Search("me", 10)
+
+	x.Put(Item[string]{ID: "1", Text: "Mew", Weight: 1, Value: "Mew"})
+	if got := x.Search("mu", 10); len(got) != 0 {
+		t.Errorf("Search(mu) after rename = %q, want none", got)
+	}
+	if got, want := x.Search("me", 10), []string{"Metallica", "Mew"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Search(me) after rename = %q, want %q", got, want)
+	}
+	if item, ok := x.Get("1"); !ok || item.Text != "Mew" {
+		t.Errorf("Get(1) = %+v, %v, want the renamed item", item, ok)
+	}
+
+	x.Put(Item[string]{ID: "7", Text: "Mumford & Sons", Weight: 2, Value: "Mumford & Sons"})
+	if got, want := x.Search("mu", 10), []string{"Mumford & Sons"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Search(mu) after put = %q, want %q", got, want)
+	}
+}
+
+func TestIndexPutReweights(t *testing.T) {
+	x := newTestIndex()
+	x.Search("m", 10)
+	x.Put(Item[string]{ID: "1", Text: "Muse", Weight: 9, Value: "Muse"})
+	if got, want := x.Search("m", 10), []string{"Muse", "Metallica"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Search(m) after reweight = %q, want %q", got, want)
+	}
+}
+
+func TestIndexRemove(t *testing.T) {
+	x := newTestIndex()
+	x.Search("sa", 10)
+
+	x.Remove("5")
+	x.Remove("unknown")
+	if got, want := x.Search("sa", 10), []string{"Black Sabbath"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Search(sa) after remove = %q, want %q", got, want)
+	}
+	if got := x.Search("bloody", 10); len(got) != 0 {
+		t.Errorf("Search(bloody) after remove = %q, want none", got)
+	}
+	if _, ok := x.Get("5"); ok {
+		t.Error("Get(5) found a removed item")
+	}
+}
+
+func TestIndexReset(t *testing.T) {
+	x := newTestIndex()
+	x.Search("m", 10)
+	x.Reset([]Item[string]{{ID: "9", Text: "Motörhead", Value: "Motörhead"}})
+	if got, want := x.Search("m", 10), []string{"Motörhead"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Search(m) after reset = %q, want %q", got, want)
+	}
+	if _, ok := x.Get("1"); ok {
+		t.Error("Get(1) found an item dropped by Reset")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"":                   "",
+		"Muse":               "muse",
+		"  AC/DC -- Live!  ": "ac dc live",
+		"Кино":               "kino",
+		"Щелкунчик":          "schelkunchik",
+		"Ёжик в тумане":      "ezhik v tumane",
+		"Объект":             "obekt",
+		"Beyoncé":            "beyoncé",
+		"Blink-182":          "blink 182",
+	}
+	for text, want := range tests {
+		if got := Normalize(text); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", text, got, want)
+		}
+	}
+}